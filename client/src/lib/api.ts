@@ -11,11 +11,58 @@ export function setAuthToken(token: string): void {
 
 export function clearAuthToken(): void {
   localStorage.removeItem('auth_token')
+  localStorage.removeItem('refresh_token')
 }
 
-function getAuthHeaders(): HeadersInit {
+function setRefreshToken(token: string): void {
+  localStorage.setItem('refresh_token', token)
+}
+
+// Renew the access token with the stored refresh token (rotated on every use)
+let refreshInFlight: Promise<boolean> | null = null
+
+async function refreshSession(): Promise<boolean> {
+  if (!refreshInFlight) {
+    refreshInFlight = (async () => {
+      const response = await fetch(`${API_BASE_URL}/api/auth/refresh`, {
+        method: 'POST',
+        headers: { 'Content-Type': 'application/json' },
+        credentials: 'include',
+        body: JSON.stringify({ refresh_token: localStorage.getItem('refresh_token') || '' }),
+      })
+      if (!response.ok) {
+        clearAuthToken()
+        return false
+      }
+      const data = await response.json()
+      setAuthToken(data.token)
+      setRefreshToken(data.refresh_token)
+      return true
+    })().finally(() => {
+      refreshInFlight = null
+    })
+  }
+  return refreshInFlight
+}
+
+// fetch with auth headers that transparently refreshes an expired access token once
+async function authFetch(url: string, init: RequestInit = {}, extraHeaders: Record<string, string> = {}): Promise<Response> {
+  const response = await fetch(url, { ...init, headers: { ...getAuthHeaders(), ...extraHeaders } })
+  if (response.status !== 401) {
+    return response
+  }
+
+  const body = await response.clone().json().catch(() => ({}))
+  if (body.code !== 'token_expired' || !(await refreshSession())) {
+    return response
+  }
+
+  return fetch(url, { ...init, headers: { ...getAuthHeaders(), ...extraHeaders } })
+}
+
+function getAuthHeaders(): Record<string, string> {
   const token = getAuthToken()
-  const headers: HeadersInit = {
+  const headers: Record<string, string> = {
     'Content-Type': 'application/json',
   }
   if (token) {
@@ -30,6 +77,22 @@ interface FetchPostsParams {
   sort: string
   dateRange?: string
   limit: number
+  cursor?: string // From a previous response, to load the next page
+  filters?: PostFilters
+}
+
+// Server-side post filters; unset fields don't filter
+export interface PostFilters {
+  minScore?: number
+  minComments?: number
+  maxAge?: string // Go duration, e.g. "24h"
+  nsfw?: 'include' | 'exclude' | 'only'
+  spoilers?: 'include' | 'exclude' | 'only'
+  flairs?: string[]
+  excludeFlairs?: string[]
+  domains?: string[]
+  excludeDomains?: string[]
+  kinds?: string[] // self, link, image, video, gallery
 }
 
 export interface RedditPost {
@@ -45,15 +108,64 @@ export interface RedditPost {
   thumbnail: string
   selftext: string
   is_video: boolean
+  is_self: boolean
+  is_gallery: boolean
+  over_18: boolean
+  spoiler: boolean
+  stickied: boolean
+  domain: string
+  post_hint?: string
+  link_flair_text: string | null
+  author_flair_text?: string
+  upvote_ratio: number
+  preview?: RedditPreview
+  gallery_data?: { items: { media_id: string; id: number; caption?: string; outbound_url?: string }[] }
+  media_metadata?: Record<string, RedditMediaMetadata>
+  secure_media?: RedditMedia
+  crosspost_parent?: string
+  crosspost_parent_list?: RedditPost[]
 }
 
-interface FetchPostsResponse {
+export interface RedditImageSource {
+  url: string
+  width: number
+  height: number
+}
+
+export interface RedditPreview {
+  images: { id: string; source: RedditImageSource; resolutions: RedditImageSource[] }[]
+  enabled: boolean
+}
+
+// Gallery media as Reddit sends it: e is Image or AnimatedImage, s the full-size rendition
+export interface RedditMediaMetadata {
+  id: string
+  status: string
+  e: string
+  m: string
+  s: { u?: string; gif?: string; mp4?: string; x: number; y: number }
+}
+
+export interface RedditMedia {
+  type?: string
+  reddit_video?: { fallback_url: string; hls_url?: string; dash_url?: string; duration: number; width: number; height: number; is_gif: boolean }
+  oembed?: { provider_name: string; title?: string; thumbnail_url?: string; width?: number; height?: number; html?: string }
+}
+
+export interface SubredditError {
+  subreddit: string
+  error: string
+}
+
+export interface FetchPostsResponse {
   posts: RedditPost[]
   count: number
+  errors: SubredditError[] // Subreddits that couldn't be fetched
+  cursor: string // Pass back to load the next page; empty when there are no more posts
 }
 
 // Fetch Reddit posts with authentication
-export async function fetchRedditPosts(params: FetchPostsParams): Promise<RedditPost[]> {
+export async function fetchRedditPosts(params: FetchPostsParams): Promise<FetchPostsResponse> {
   const queryParams = new URLSearchParams()
 
   // Add subreddits (comma-separated)
@@ -80,31 +192,54 @@ export async function fetchRedditPosts(params: FetchPostsParams): Promise<Reddit
   // Add limit
   queryParams.append('limit', params.limit.toString())
 
+  // Add post filters
+  const filters = params.filters
+  if (filters) {
+    if (filters.minScore !== undefined) queryParams.append('min_score', filters.minScore.toString())
+    if (filters.minComments !== undefined) queryParams.append('min_comments', filters.minComments.toString())
+    if (filters.maxAge) queryParams.append('max_age', filters.maxAge)
+    if (filters.nsfw) queryParams.append('nsfw', filters.nsfw)
+    if (filters.spoilers) queryParams.append('spoilers', filters.spoilers)
+    if (filters.flairs?.length) queryParams.append('flair', filters.flairs.join(','))
+    if (filters.excludeFlairs?.length) queryParams.append('exclude_flair', filters.excludeFlairs.join(','))
+    if (filters.domains?.length) queryParams.append('domain', filters.domains.join(','))
+    if (filters.excludeDomains?.length) queryParams.append('exclude_domain', filters.excludeDomains.join(','))
+    if (filters.kinds?.length) queryParams.append('kind', filters.kinds.join(','))
+  }
+
+  // Continue a previous query
+  if (params.cursor) {
+    queryParams.append('cursor', params.cursor)
+  }
+
   const url = `${API_BASE_URL}/api/reddit/posts?${queryParams.toString()}`
 
-  const response = await fetch(url, {
+  const response = await authFetch(url, {
     method: 'GET',
-    headers: getAuthHeaders(),
   })
 
   if (!response.ok) {
     if (response.status === 401) {
       throw new Error('Not authenticated. Please log in.')
     }
+    if (response.status === 429) {
+      const data = await response.json().catch(() => ({}))
+      const wait = data.retry_after ? ` Try again in ${data.retry_after} seconds.` : ''
+      throw new Error(`Reddit rate limit reached.${wait}`)
+    }
     throw new Error(`Failed to fetch posts: ${response.statusText}`)
   }
 
   const data: FetchPostsResponse = await response.json()
-  return data.posts
+  return { ...data, errors: data.errors || [], cursor: data.cursor || '' }
 }
 
 // Get current user
 export async function getCurrentUser() {
   const url = `${API_BASE_URL}/api/auth/user`
 
-  const response = await fetch(url, {
+  const response = await authFetch(url, {
     method: 'GET',
-    headers: getAuthHeaders(),
   })
 
   if (!response.ok) {
@@ -118,26 +253,27 @@ export async function getCurrentUser() {
   return data.user
 }
 
-// Store token from OAuth callback
-export async function storeAuthToken(token: string) {
-  // Validate token by fetching user
-  const url = `${API_BASE_URL}/api/auth/user`
+// Redeem the one-time login code from the OAuth redirect for a token
+export async function exchangeAuthCode(code: string) {
+  const url = `${API_BASE_URL}/api/auth/exchange-token`
 
   const response = await fetch(url, {
-    method: 'GET',
+    method: 'POST',
     headers: {
       'Content-Type': 'application/json',
-      'Authorization': `Bearer ${token}`
     },
+    credentials: 'include',
+    body: JSON.stringify({ code }),
   })
 
   if (!response.ok) {
-    throw new Error(`Invalid token: ${response.statusText}`)
+    throw new Error(`Login failed: ${response.statusText}`)
   }
 
   const data = await response.json()
-  // Store token in localStorage
-  setAuthToken(token)
+  // Store tokens in localStorage
+  setAuthToken(data.token)
+  setRefreshToken(data.refresh_token)
   return data.user
 }
 
@@ -145,38 +281,26 @@ export async function storeAuthToken(token: string) {
 export async function logout() {
   const url = `${API_BASE_URL}/api/auth/logout`
 
-  const response = await fetch(url, {
+  const response = await authFetch(url, {
     method: 'POST',
-    headers: getAuthHeaders(),
+    body: JSON.stringify({ refresh_token: localStorage.getItem('refresh_token') || '' }),
   })
 
+  // Clear token from localStorage even if the server no longer knew the session
+  clearAuthToken()
+
   if (!response.ok) {
     throw new Error(`Failed to logout: ${response.statusText}`)
   }
 
-  // Clear token from localStorage
-  clearAuthToken()
-
   return response.json()
 }
 
 // Get Notion OAuth URL
+// The browser navigates to the API first so it can set the OAuth verifier
+// cookie on its own domain, then gets redirected on to Notion.
 export async function getNotionAuthUrl() {
-  const url = `${API_BASE_URL}/api/auth/notion/login`
-
-  const response = await fetch(url, {
-    method: 'GET',
-    headers: {
-      'Content-Type': 'application/json',
-    },
-  })
-
-  if (!response.ok) {
-    throw new Error(`Failed to get auth URL: ${response.statusText}`)
-  }
-
-  const data = await response.json()
-  return data.url
+  return `${API_BASE_URL}/api/auth/notion/login?redirect=true`
 }
 
 // Notion API functions
@@ -197,6 +321,7 @@ export interface SaveToNotionResponse {
   notion_page_id: string
   notion_page_url: string
   message: string
+  already_saved?: boolean
 }
 
 export interface NotionDatabase {
@@ -205,34 +330,209 @@ export interface NotionDatabase {
   url: string
 }
 
+export interface NotionWorkspace {
+  id: number
+  workspace_id: string
+  workspace_name: string
+  workspace_icon: string
+  bot_id: string
+  updated_at: string
+}
+
+// Adds the workspace selector to a /api/notion URL; without it the server
+// uses the most recently connected workspace
+function withWorkspace(url: string, workspaceId?: string): string {
+  return workspaceId ? `${url}?workspace_id=${encodeURIComponent(workspaceId)}` : url
+}
+
 // Save a Reddit post to Notion
-export async function saveToNotion(post: SaveToNotionRequest): Promise<SaveToNotionResponse> {
-  const url = `${API_BASE_URL}/api/notion/save`
+export async function saveToNotion(post: SaveToNotionRequest, workspaceId?: string): Promise<SaveToNotionResponse> {
+  const url = withWorkspace(`${API_BASE_URL}/api/notion/save`, workspaceId)
 
-  const response = await fetch(url, {
+  const response = await authFetch(url, {
     method: 'POST',
-    headers: getAuthHeaders(),
     body: JSON.stringify(post),
   })
 
+  // Already saved to this database - hand back the existing page
+  if (response.status === 409) {
+    const data = await response.json()
+    return { ...data, success: false, message: data.error, already_saved: true }
+  }
+
   if (!response.ok) {
     if (response.status === 401) {
       throw new Error('Not authenticated. Please log in.')
     }
     const errorData = await response.json()
+    // 422: the database's stored property mapping no longer fits its columns
+    if (response.status === 422 && errorData.problems?.length) {
+      throw new Error(`${errorData.error}: ${errorData.problems.join('; ')}`)
+    }
     throw new Error(errorData.error || `Failed to save to Notion: ${response.statusText}`)
   }
 
   return response.json()
 }
 
+export interface JobItem {
+  id: number
+  position: number
+  key: string // Reddit ID for bulk saves
+  status: 'pending' | 'running' | 'succeeded' | 'skipped' | 'failed'
+  error?: string
+  notion_page_id?: string
+  notion_page_url?: string
+}
+
+export interface Job {
+  id: number
+  kind: string
+  status: 'pending' | 'running' | 'completed' | 'failed'
+  database_id: string
+  total: number
+  succeeded: number
+  skipped: number
+  failed: number
+  started_at: string | null
+  finished_at: string | null
+  items?: JobItem[]
+}
+
+export interface BulkSaveResponse {
+  job_id: number
+  status: Job['status']
+  total: number
+}
+
+// Queue posts to be saved to a database in the background; poll getJob for progress
+export async function bulkSaveToNotion(posts: Omit<SaveToNotionRequest, 'database_id'>[], databaseId: string, workspaceId?: string): Promise<BulkSaveResponse> {
+  const url = withWorkspace(`${API_BASE_URL}/api/notion/save/bulk`, workspaceId)
+
+  const response = await authFetch(url, {
+    method: 'POST',
+    body: JSON.stringify({ database_id: databaseId, posts }),
+  })
+
+  if (!response.ok) {
+    if (response.status === 401) {
+      throw new Error('Not authenticated. Please log in.')
+    }
+    const errorData = await response.json().catch(() => ({}))
+    throw new Error(errorData.error || `Failed to queue posts: ${response.statusText}`)
+  }
+
+  return response.json()
+}
+
+// Get a background job with the status of each item
+export async function getJob(jobId: number): Promise<Job> {
+  const response = await authFetch(`${API_BASE_URL}/api/jobs/${jobId}`, {
+    method: 'GET',
+  })
+
+  if (!response.ok) {
+    if (response.status === 401) {
+      throw new Error('Not authenticated. Please log in.')
+    }
+    throw new Error(`Failed to get job: ${response.statusText}`)
+  }
+
+  return response.json()
+}
+
+export interface JobProgress {
+  status: Job['status']
+  total: number
+  succeeded: number
+  skipped: number
+  failed: number
+}
+
+export type JobEvent =
+  | { type: 'started' | 'finished'; data: JobProgress }
+  | {
+    type: 'item'
+    data: JobProgress & {
+      item_id: number
+      position: number
+      key: string
+      item_status: JobItem['status']
+      error?: string
+      notion_page_id?: string
+      notion_page_url?: string
+    }
+  }
+
+// Stream a job's progress until it finishes. The server sends Server-Sent
+// Events; they're read with fetch rather than EventSource so the Authorization
+// header can be sent, and the stream is resumed from the last event ID if the
+// connection drops.
+export async function watchJob(jobId: number, onEvent: (event: JobEvent) => void, signal?: AbortSignal): Promise<void> {
+  let lastEventId = ''
+  let failures = 0
+
+  while (!signal?.aborted) {
+    try {
+      const response = await authFetch(`${API_BASE_URL}/api/jobs/${jobId}/events`, { method: 'GET', signal },
+        lastEventId ? { 'Last-Event-ID': lastEventId } : {})
+      if (!response.ok || !response.body) {
+        throw new Error(`Failed to watch job: ${response.statusText}`)
+      }
+
+      const reader = response.body.pipeThrough(new TextDecoderStream()).getReader()
+      let buffer = ''
+      for (;;) {
+        const { value, done } = await reader.read()
+        if (done) {
+          break
+        }
+        buffer += value
+
+        // Events are separated by a blank line
+        let end
+        while ((end = buffer.indexOf('\n\n')) !== -1) {
+          const block = buffer.slice(0, end)
+          buffer = buffer.slice(end + 2)
+
+          let type = 'message'
+          let data = ''
+          for (const line of block.split('\n')) {
+            if (line.startsWith('id: ')) lastEventId = line.slice(4)
+            else if (line.startsWith('event: ')) type = line.slice(7)
+            else if (line.startsWith('data: ')) data += line.slice(6)
+          }
+          if (!data) {
+            continue // Keep-alive comment
+          }
+
+          onEvent({ type, data: JSON.parse(data) } as JobEvent)
+          if (type === 'finished') {
+            return
+          }
+        }
+      }
+      failures = 0
+    } catch (err) {
+      if (signal?.aborted) {
+        return
+      }
+      if (++failures >= 5) {
+        throw err
+      }
+    }
+
+    // The stream ended before the job finished; reconnect and resume
+    await new Promise(resolve => setTimeout(resolve, 1000 * Math.max(1, failures)))
+  }
+}
+
 // Get user's Notion databases
-export async function getNotionDatabases(): Promise<NotionDatabase[]> {
-  const url = `${API_BASE_URL}/api/notion/databases`
+export async function getNotionDatabases(workspaceId?: string): Promise<NotionDatabase[]> {
+  const url = withWorkspace(`${API_BASE_URL}/api/notion/databases`, workspaceId)
 
-  const response = await fetch(url, {
+  const response = await authFetch(url, {
     method: 'GET',
-    headers: getAuthHeaders(),
   })
 
   if (!response.ok) {
@@ -246,13 +546,129 @@ export async function getNotionDatabases(): Promise<NotionDatabase[]> {
   return data.databases
 }
 
+// Where a database property's value comes from: a Reddit field, or a constant when field is empty
+export interface PropertySource {
+  field?: string
+  constant?: string
+}
+
+export type PropertyMapping = Record<string, PropertySource>
+
+export interface DatabaseMappingResponse {
+  database_id: string
+  mapping: PropertyMapping
+  suggested: boolean // True until a mapping has been saved
+  problems: string[] // Where the stored mapping no longer fits the database
+  properties: { name: string; type: string }[]
+  fields: string[] // Reddit fields a property can be mapped to
+}
+
+// Get the property mapping used when saving to a database
+export async function getDatabaseMapping(databaseId: string, workspaceId?: string): Promise<DatabaseMappingResponse> {
+  const url = withWorkspace(`${API_BASE_URL}/api/notion/databases/${encodeURIComponent(databaseId)}/mapping`, workspaceId)
+
+  const response = await authFetch(url, {
+    method: 'GET',
+  })
+
+  if (!response.ok) {
+    if (response.status === 401) {
+      throw new Error('Not authenticated. Please log in.')
+    }
+    throw new Error(`Failed to get mapping: ${response.statusText}`)
+  }
+
+  return response.json()
+}
+
+// Save the property mapping for a database; it is checked against the database's columns
+export async function saveDatabaseMapping(databaseId: string, mapping: PropertyMapping, workspaceId?: string): Promise<void> {
+  const url = withWorkspace(`${API_BASE_URL}/api/notion/databases/${encodeURIComponent(databaseId)}/mapping`, workspaceId)
+
+  const response = await authFetch(url, {
+    method: 'PUT',
+    body: JSON.stringify({ mapping }),
+  })
+
+  if (!response.ok) {
+    if (response.status === 401) {
+      throw new Error('Not authenticated. Please log in.')
+    }
+    const data = await response.json().catch(() => ({}))
+    const problems = data.problems?.length ? `: ${data.problems.join('; ')}` : ''
+    throw new Error(`${data.error || 'Failed to save mapping'}${problems}`)
+  }
+}
+
+export interface SchemaValidationResponse {
+  database_id: string
+  valid: boolean
+  suggested: boolean // True when no mapping is stored and the suggestion was checked
+  problems: string[]
+  properties: { name: string; type: string }[]
+}
+
+// Check a database's mapping against its schema, optionally refetching the schema from Notion
+export async function validateDatabaseSchema(databaseId: string, refresh = false, workspaceId?: string): Promise<SchemaValidationResponse> {
+  const params = new URLSearchParams()
+  if (refresh) params.set('refresh', 'true')
+  if (workspaceId) params.set('workspace_id', workspaceId)
+  const query = params.toString()
+  const url = `${API_BASE_URL}/api/notion/databases/${encodeURIComponent(databaseId)}/validate${query ? `?${query}` : ''}`
+
+  const response = await authFetch(url, {
+    method: 'GET',
+  })
+
+  if (!response.ok) {
+    if (response.status === 401) {
+      throw new Error('Not authenticated. Please log in.')
+    }
+    throw new Error(`Failed to validate database: ${response.statusText}`)
+  }
+
+  return response.json()
+}
+
+// Get the Notion workspaces the user has connected
+export async function getNotionWorkspaces(): Promise<NotionWorkspace[]> {
+  const url = `${API_BASE_URL}/api/notion/workspaces`
+
+  const response = await authFetch(url, {
+    method: 'GET',
+  })
+
+  if (!response.ok) {
+    if (response.status === 401) {
+      throw new Error('Not authenticated. Please log in.')
+    }
+    throw new Error(`Failed to get workspaces: ${response.statusText}`)
+  }
+
+  const data = await response.json()
+  return data.workspaces
+}
+
+// Disconnect a single Notion workspace
+export async function disconnectNotionWorkspace(workspaceId: string): Promise<void> {
+  const url = `${API_BASE_URL}/api/notion/workspaces/${encodeURIComponent(workspaceId)}`
+
+  const response = await authFetch(url, {
+    method: 'DELETE',
+  })
+
+  if (!response.ok) {
+    const errorData = await response.json().catch(() => ({ error: response.statusText }))
+    throw new Error(errorData.error || 'Failed to disconnect workspace')
+  }
+}
+
 // Get saved posts
 export async function getSavedPosts(): Promise<import('@/types').RedditPost[]> {
   const url = `${API_BASE_URL}/api/notion/saved-posts`
 
-  const response = await fetch(url, {
+  const response = await authFetch(url, {
     method: 'GET',
-    headers: getAuthHeaders(),
   })
 
   if (!response.ok) {
@@ -272,6 +688,7 @@ export async function getSavedPosts(): Promise<import('@/types').RedditPost[]> {
     saved_at: string
     url: string
     notion_page_url: string
+    notion_database_id: string
   }
 
   const data: { posts: SavedPostBackend[] } = await response.json()
@@ -287,17 +704,18 @@ export async function getSavedPosts(): Promise<import('@/types').RedditPost[]> {
     created: post.saved_at,
     url: post.url,
     saved: true,
-    notionPageUrl: post.notion_page_url
+    notionPageUrl: post.notion_page_url,
+    notionDatabaseId: post.notion_database_id || undefined
   }))
 }
 
-// Delete a saved post
-export async function deleteSavedPost(redditId: string): Promise<void> {
-  const url = `${API_BASE_URL}/api/notion/saved-posts/${redditId}`
+// Delete a saved post (from one database, or from every database it was saved to)
+export async function deleteSavedPost(redditId: string, databaseId?: string): Promise<void> {
+  const query = databaseId ? `?database_id=${encodeURIComponent(databaseId)}` : ''
+  const url = `${API_BASE_URL}/api/notion/saved-posts/${redditId}${query}`
 
-  const response = await fetch(url, {
+  const response = await authFetch(url, {
     method: 'DELETE',
-    headers: getAuthHeaders(),
   })
 
   if (!response.ok) {
@@ -305,3 +723,103 @@ export async function deleteSavedPost(redditId: string): Promise<void> {
     throw new Error(errorData.error || 'Failed to delete post')
   }
 }
+
+// Reddit account linking
+
+export interface RedditConnection {
+  reddit_user_id: string
+  username: string
+  scope: string
+  updated_at: string
+}
+
+// job_id is missing when every fetched item was already saved
+export interface ImportSavedResponse extends Partial<Omit<BulkSaveResponse, 'total'>> {
+  total: number
+  skipped: number
+  after: string
+}
+
+// Get the user's linked Reddit account, or null when none is linked
+export async function getRedditConnection(): Promise<RedditConnection | null> {
+  const url = `${API_BASE_URL}/api/reddit/connection`
+
+  const response = await authFetch(url, {
+    method: 'GET',
+  })
+
+  if (!response.ok) {
+    throw new Error(`Failed to get Reddit connection: ${response.statusText}`)
+  }
+
+  const data = await response.json()
+  return data.connected ? data.connection : null
+}
+
+// Start linking a Reddit account; returns the Reddit consent URL to navigate to
+export async function getRedditConnectUrl(): Promise<string> {
+  const url = `${API_BASE_URL}/api/reddit/connect`
+
+  const response = await authFetch(url, {
+    method: 'POST',
+  })
+
+  if (!response.ok) {
+    const errorData = await response.json().catch(() => ({ error: response.statusText }))
+    throw new Error(errorData.error || 'Failed to connect Reddit')
+  }
+
+  const data = await response.json()
+  return data.url
+}
+
+// Finish linking with the code and state Reddit redirected back with
+export async function completeRedditConnect(code: string, state: string): Promise<RedditConnection> {
+  const url = `${API_BASE_URL}/api/reddit/connect/callback`
+
+  const response = await authFetch(url, {
+    method: 'POST',
+    body: JSON.stringify({ code, state }),
+  })
+
+  if (!response.ok) {
+    const errorData = await response.json().catch(() => ({ error: response.statusText }))
+    throw new Error(errorData.error || 'Failed to connect Reddit')
+  }
+
+  const data = await response.json()
+  return data.connection
+}
+
+// Unlink the user's Reddit account
+export async function disconnectReddit(): Promise<void> {
+  const url = `${API_BASE_URL}/api/reddit/connection`
+
+  const response = await authFetch(url, {
+    method: 'DELETE',
+  })
+
+  if (!response.ok) {
+    const errorData = await response.json().catch(() => ({ error: response.statusText }))
+    throw new Error(errorData.error || 'Failed to disconnect Reddit')
+  }
+}
+
+// Queue Reddit saved items to be imported into a Notion database (up to 100
+// per call; pass the returned cursor as `after` to continue); watch the
+// returned job for progress
+export async function importRedditSaved(databaseId: string, after?: string): Promise<ImportSavedResponse> {
+  const url = `${API_BASE_URL}/api/reddit/saved/import`
+
+  const response = await authFetch(url, {
+    method: 'POST',
+    body: JSON.stringify({ database_id: databaseId, after: after || '' }),
+  })
+
+  if (!response.ok) {
+    const errorData = await response.json().catch(() => ({ error: response.statusText }))
+    throw new Error(errorData.error || 'Failed to import saved items')
+  }
+
+  return response.json()
+}
@@ -10,6 +10,7 @@ export interface RedditPost {
   url: string
   saved: boolean
   notionPageUrl?: string
+  notionDatabaseId?: string
 }
 
 export interface FilterOptions {
@@ -19,4 +20,10 @@ export interface FilterOptions {
   sortBy: string
   numberOfPosts: number
   filterType: 'all' | 'unsaved'
+  minScore?: number
+  minComments?: number
+  hideNsfw: boolean
+  postKinds: PostKind[] // Empty means every kind
 }
+
+export type PostKind = 'self' | 'link' | 'image' | 'video' | 'gallery'
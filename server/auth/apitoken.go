@@ -0,0 +1,115 @@
+package auth
+
+import (
+	"errors"
+	"fmt"
+	"strings"
+	"time"
+
+	"re2no/database"
+	"re2no/models"
+)
+
+// APITokenPrefix marks personal access tokens so they can be told apart from JWTs
+const APITokenPrefix = "r2n_"
+
+// Token scopes; write implies read
+const (
+	ScopeRead  = "read"
+	ScopeWrite = "write"
+)
+
+// apiTokenDisplayLength is how much of a token is kept in plaintext for display
+const apiTokenDisplayLength = len(APITokenPrefix) + 6
+
+var (
+	ErrInvalidAPIToken = errors.New("invalid or expired API token")
+	ErrInvalidScope    = errors.New("scope must be \"read\" or \"write\"")
+)
+
+// IsAPIToken reports whether a bearer credential is a personal access token
+func IsAPIToken(token string) bool {
+	return strings.HasPrefix(token, APITokenPrefix)
+}
+
+// CreateAPIToken creates a personal access token and returns its plaintext,
+// which is never stored and can't be shown again
+func CreateAPIToken(userID uint, name, scope string, expiresAt *time.Time) (string, *models.APIToken, error) {
+	if scope != ScopeRead && scope != ScopeWrite {
+		return "", nil, ErrInvalidScope
+	}
+
+	secret, err := randomToken(32)
+	if err != nil {
+		return "", nil, fmt.Errorf("failed to generate API token: %w", err)
+	}
+	token := APITokenPrefix + secret
+
+	row := models.APIToken{
+		UserID:    userID,
+		Name:      name,
+		Prefix:    token[:apiTokenDisplayLength],
+		TokenHash: hashToken(token),
+		Scope:     scope,
+		ExpiresAt: expiresAt,
+	}
+	if err := database.DB.Create(&row).Error; err != nil {
+		return "", nil, fmt.Errorf("failed to store API token: %w", err)
+	}
+
+	return token, &row, nil
+}
+
+// ValidateAPIToken looks up an active API token and records that it was used
+func ValidateAPIToken(token string) (*models.APIToken, error) {
+	if !IsAPIToken(token) {
+		return nil, ErrInvalidAPIToken
+	}
+
+	var row models.APIToken
+	if err := database.DB.Where("token_hash = ?", hashToken(token)).First(&row).Error; err != nil {
+		return nil, ErrInvalidAPIToken
+	}
+	if row.RevokedAt != nil {
+		return nil, ErrInvalidAPIToken
+	}
+	now := time.Now()
+	if row.ExpiresAt != nil && now.After(*row.ExpiresAt) {
+		return nil, ErrInvalidAPIToken
+	}
+
+	// Best effort; a failed timestamp update shouldn't fail the request
+	database.DB.Model(&row).UpdateColumn("last_used_at", now)
+	row.LastUsedAt = &now
+
+	return &row, nil
+}
+
+// ListAPITokens returns a user's tokens that haven't been revoked, newest first
+func ListAPITokens(userID uint) ([]models.APIToken, error) {
+	var tokens []models.APIToken
+	err := database.DB.
+		Where("user_id = ? AND revoked_at IS NULL", userID).
+		Order("created_at DESC").
+		Find(&tokens).Error
+	return tokens, err
+}
+
+// RevokeAPIToken revokes one of a user's API tokens
+func RevokeAPIToken(userID, tokenID uint) error {
+	result := database.DB.Model(&models.APIToken{}).
+		Where("id = ? AND user_id = ? AND revoked_at IS NULL", tokenID, userID).
+		Update("revoked_at", time.Now())
+	if result.Error != nil {
+		return result.Error
+	}
+	if result.RowsAffected == 0 {
+		return ErrInvalidAPIToken
+	}
+	return nil
+}
+
+// ScopeAllows reports whether a granted scope covers the required one
+func ScopeAllows(granted, required string) bool {
+	return granted == required || (granted == ScopeWrite && required == ScopeRead)
+}
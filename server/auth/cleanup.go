@@ -0,0 +1,41 @@
+package auth
+
+import (
+	"log"
+	"time"
+)
+
+// StartCleanup periodically purges expired OAuth states, login codes, refresh tokens
+// and revocation entries in the background
+func StartCleanup(interval time.Duration) {
+	go func() {
+		ticker := time.NewTicker(interval)
+		defer ticker.Stop()
+
+		for range ticker.C {
+			if deleted, err := CleanupExpiredOAuthStates(); err != nil {
+				log.Printf("[Auth Cleanup] Failed to remove expired OAuth states: %v", err)
+			} else if deleted > 0 {
+				log.Printf("[Auth Cleanup] Removed %d expired OAuth states", deleted)
+			}
+
+			if deleted, err := CleanupExpiredAuthCodes(); err != nil {
+				log.Printf("[Auth Cleanup] Failed to remove expired login codes: %v", err)
+			} else if deleted > 0 {
+				log.Printf("[Auth Cleanup] Removed %d expired login codes", deleted)
+			}
+
+			if deleted, err := CleanupExpiredRefreshTokens(); err != nil {
+				log.Printf("[Auth Cleanup] Failed to remove expired refresh tokens: %v", err)
+			} else if deleted > 0 {
+				log.Printf("[Auth Cleanup] Removed %d expired refresh tokens", deleted)
+			}
+
+			if deleted, err := CleanupRevokedTokens(); err != nil {
+				log.Printf("[Auth Cleanup] Failed to remove stale revocations: %v", err)
+			} else if deleted > 0 {
+				log.Printf("[Auth Cleanup] Removed %d stale revocations and logins", deleted)
+			}
+		}
+	}()
+}
@@ -0,0 +1,62 @@
+package auth
+
+import (
+	"errors"
+	"fmt"
+	"time"
+
+	"re2no/database"
+	"re2no/models"
+
+	"gorm.io/gorm/clause"
+)
+
+// AuthCodeTTL is how long the frontend has to redeem a login code
+const AuthCodeTTL = 60 * time.Second
+
+var ErrInvalidAuthCode = errors.New("invalid, expired or already used code")
+
+// IssueAuthCode creates a short-lived, single-use code that the frontend
+// exchanges for a JWT, so the token itself never appears in a URL.
+// Only a hash of the code is stored.
+func IssueAuthCode(userID uint) (string, error) {
+	code, err := randomToken(32)
+	if err != nil {
+		return "", fmt.Errorf("failed to generate code: %w", err)
+	}
+
+	row := models.AuthCode{
+		CodeHash:  hashToken(code),
+		UserID:    userID,
+		ExpiresAt: time.Now().Add(AuthCodeTTL),
+	}
+	if err := database.DB.Create(&row).Error; err != nil {
+		return "", fmt.Errorf("failed to store code: %w", err)
+	}
+
+	return code, nil
+}
+
+// RedeemAuthCode atomically consumes a code and returns the user it was issued to
+func RedeemAuthCode(code string) (uint, error) {
+	if code == "" {
+		return 0, ErrInvalidAuthCode
+	}
+
+	var row models.AuthCode
+	result := database.DB.Clauses(clause.Returning{}).Where("code_hash = ?", hashToken(code)).Delete(&row)
+	if result.Error != nil {
+		return 0, fmt.Errorf("failed to redeem code: %w", result.Error)
+	}
+	if result.RowsAffected == 0 || time.Now().After(row.ExpiresAt) {
+		return 0, ErrInvalidAuthCode
+	}
+
+	return row.UserID, nil
+}
+
+// CleanupExpiredAuthCodes deletes codes that were never redeemed
+func CleanupExpiredAuthCodes() (int64, error) {
+	result := database.DB.Where("expires_at < ?", time.Now()).Delete(&models.AuthCode{})
+	return result.RowsAffected, result.Error
+}
@@ -6,10 +6,19 @@ import (
 	"time"
 
 	"github.com/golang-jwt/jwt/v5"
+	"github.com/google/uuid"
 )
 
 var jwtSecret []byte
 
+// AccessTokenTTL is the lifetime of an access token; clients renew it with a refresh token
+const AccessTokenTTL = 15 * time.Minute
+
+// tokenUseAccess marks a JWT as an API access token
+const tokenUseAccess = "access"
+
+var ErrWrongTokenUse = errors.New("token is not an access token")
+
 // InitJWT initializes the JWT secret from environment variable
 func InitJWT() {
 	secret := os.Getenv("JWT_SECRET")
@@ -21,19 +30,24 @@ func InitJWT() {
 
 // Claims represents the JWT claims
 type Claims struct {
-	UserID uint   `json:"user_id"`
-	Email  string `json:"email"`
+	UserID   uint   `json:"user_id"`
+	Email    string `json:"email"`
+	TokenUse string `json:"token_use"`
+	LoginID  uint   `json:"sid"` // The login (device session) this token belongs to
 	jwt.RegisteredClaims
 }
 
-// GenerateToken generates a new JWT token for a user
-func GenerateToken(userID uint, email string) (string, error) {
-	expirationTime := time.Now().Add(24 * time.Hour) // Token valid for 24 hours
+// GenerateToken generates a new short-lived access token for a user's login
+func GenerateToken(userID uint, email string, loginID uint) (string, error) {
+	expirationTime := time.Now().Add(AccessTokenTTL)
 
 	claims := &Claims{
-		UserID: userID,
-		Email:  email,
+		UserID:   userID,
+		Email:    email,
+		TokenUse: tokenUseAccess,
+		LoginID:  loginID,
 		RegisteredClaims: jwt.RegisteredClaims{
+			ID:        uuid.New().String(), // jti, used for revocation
 			ExpiresAt: jwt.NewNumericDate(expirationTime),
 			IssuedAt:  jwt.NewNumericDate(time.Now()),
 			Issuer:    "re2no",
@@ -49,7 +63,7 @@ func GenerateToken(userID uint, email string) (string, error) {
 	return tokenString, nil
 }
 
-// ValidateToken validates a JWT token and returns the claims
+// ValidateToken validates an access token and returns the claims
 func ValidateToken(tokenString string) (*Claims, error) {
 	claims := &Claims{}
 
@@ -69,16 +83,15 @@ func ValidateToken(tokenString string) (*Claims, error) {
 		return nil, errors.New("invalid token")
 	}
 
+	if claims.TokenUse != tokenUseAccess {
+		return nil, ErrWrongTokenUse
+	}
+
 	return claims, nil
 }
 
-// RefreshToken generates a new token with extended expiration
-func RefreshToken(tokenString string) (string, error) {
-	claims, err := ValidateToken(tokenString)
-	if err != nil {
-		return "", err
-	}
-
-	// Generate new token with the same user info
-	return GenerateToken(claims.UserID, claims.Email)
+// IsTokenExpired reports whether a validation error was caused by expiry,
+// in which case the client should use its refresh token
+func IsTokenExpired(err error) bool {
+	return errors.Is(err, jwt.ErrTokenExpired)
 }
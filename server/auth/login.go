@@ -0,0 +1,163 @@
+package auth
+
+import (
+	"errors"
+	"fmt"
+	"time"
+
+	"re2no/database"
+	"re2no/models"
+
+	"github.com/google/uuid"
+	"gorm.io/gorm"
+)
+
+var ErrLoginRevoked = errors.New("login has been revoked")
+
+// CreateLogin records a new login (one per device/browser) and returns it.
+// Its family ID ties together every refresh token issued to that login.
+func CreateLogin(userID uint, userAgent, ipAddress string) (*models.Login, error) {
+	now := time.Now()
+	login := models.Login{
+		UserID:     userID,
+		FamilyID:   uuid.New().String(),
+		UserAgent:  userAgent,
+		IPAddress:  ipAddress,
+		LastSeenAt: now,
+		ExpiresAt:  now.Add(RefreshTokenTTL),
+	}
+	if err := database.DB.Create(&login).Error; err != nil {
+		return nil, fmt.Errorf("failed to record login: %w", err)
+	}
+	return &login, nil
+}
+
+// TouchLogin marks a login as used after a refresh and extends its expiry
+func TouchLogin(familyID, ipAddress string) (*models.Login, error) {
+	var login models.Login
+	if err := database.DB.Where("family_id = ?", familyID).First(&login).Error; err != nil {
+		return nil, fmt.Errorf("login not found: %w", err)
+	}
+	if login.RevokedAt != nil {
+		return nil, ErrLoginRevoked
+	}
+
+	now := time.Now()
+	login.LastSeenAt = now
+	login.ExpiresAt = now.Add(RefreshTokenTTL)
+	if ipAddress != "" {
+		login.IPAddress = ipAddress
+	}
+	if err := database.DB.Save(&login).Error; err != nil {
+		return nil, fmt.Errorf("failed to update login: %w", err)
+	}
+	return &login, nil
+}
+
+// ListActiveLogins returns a user's logins that are neither revoked nor expired
+func ListActiveLogins(userID uint) ([]models.Login, error) {
+	var logins []models.Login
+	err := database.DB.
+		Where("user_id = ? AND revoked_at IS NULL AND expires_at > ?", userID, time.Now()).
+		Order("last_seen_at DESC").
+		Find(&logins).Error
+	return logins, err
+}
+
+// RevokeLogin revokes one of a user's logins along with its refresh tokens.
+// Access tokens issued to it are rejected by IsAccessTokenRevoked from then on.
+func RevokeLogin(userID, loginID uint) error {
+	var login models.Login
+	if err := database.DB.Where("id = ? AND user_id = ?", loginID, userID).First(&login).Error; err != nil {
+		return err
+	}
+	return revokeLogins([]uint{login.ID}, []string{login.FamilyID})
+}
+
+// RevokeAllLogins revokes every login of a user, except exceptLoginID when non-zero
+func RevokeAllLogins(userID, exceptLoginID uint) (int, error) {
+	var logins []models.Login
+	query := database.DB.Where("user_id = ? AND revoked_at IS NULL", userID)
+	if exceptLoginID != 0 {
+		query = query.Where("id <> ?", exceptLoginID)
+	}
+	if err := query.Find(&logins).Error; err != nil {
+		return 0, err
+	}
+	if len(logins) == 0 {
+		return 0, nil
+	}
+
+	ids := make([]uint, len(logins))
+	families := make([]string, len(logins))
+	for i, login := range logins {
+		ids[i] = login.ID
+		families[i] = login.FamilyID
+	}
+
+	return len(logins), revokeLogins(ids, families)
+}
+
+// revokeLogins marks logins revoked and revokes their refresh token families
+func revokeLogins(ids []uint, families []string) error {
+	return database.DB.Transaction(func(tx *gorm.DB) error {
+		now := time.Now()
+		if err := tx.Model(&models.Login{}).Where("id IN ? AND revoked_at IS NULL", ids).Update("revoked_at", now).Error; err != nil {
+			return err
+		}
+		return tx.Model(&models.RefreshToken{}).
+			Where("family_id IN ? AND revoked_at IS NULL", families).
+			Update("revoked_at", now).Error
+	})
+}
+
+// RevokeAccessToken adds a single access token's jti to the revocation store
+// until the token would have expired anyway
+func RevokeAccessToken(claims *Claims) error {
+	if claims.ID == "" {
+		return nil
+	}
+	expiresAt := time.Now().Add(AccessTokenTTL)
+	if claims.ExpiresAt != nil {
+		expiresAt = claims.ExpiresAt.Time
+	}
+	row := models.RevokedToken{JTI: claims.ID, ExpiresAt: expiresAt}
+	return database.DB.Where(models.RevokedToken{JTI: claims.ID}).FirstOrCreate(&row).Error
+}
+
+// IsAccessTokenRevoked reports whether the token itself or the login it belongs to was revoked
+func IsAccessTokenRevoked(claims *Claims) (bool, error) {
+	var count int64
+	if err := database.DB.Model(&models.RevokedToken{}).Where("jti = ?", claims.ID).Count(&count).Error; err != nil {
+		return false, err
+	}
+	if count > 0 {
+		return true, nil
+	}
+
+	if claims.LoginID == 0 {
+		return false, nil
+	}
+	var login models.Login
+	if err := database.DB.Select("id", "revoked_at").First(&login, claims.LoginID).Error; err != nil {
+		if errors.Is(err, gorm.ErrRecordNotFound) {
+			return true, nil
+		}
+		return false, err
+	}
+	return login.RevokedAt != nil, nil
+}
+
+// CleanupRevokedTokens deletes revocation entries for tokens that have expired anyway,
+// along with logins that are long expired or revoked
+func CleanupRevokedTokens() (int64, error) {
+	now := time.Now()
+	result := database.DB.Where("expires_at < ?", now).Delete(&models.RevokedToken{})
+	if result.Error != nil {
+		return 0, result.Error
+	}
+	deleted := result.RowsAffected
+
+	result = database.DB.Where("expires_at < ? OR revoked_at < ?", now, now.Add(-AccessTokenTTL)).Delete(&models.Login{})
+	return deleted + result.RowsAffected, result.Error
+}
@@ -0,0 +1,120 @@
+package auth
+
+import (
+	"errors"
+	"fmt"
+	"log"
+	"time"
+
+	"re2no/database"
+	"re2no/models"
+
+	"gorm.io/gorm"
+)
+
+// RefreshTokenTTL is how long a refresh token stays valid if it isn't rotated
+const RefreshTokenTTL = 30 * 24 * time.Hour
+
+var (
+	ErrInvalidRefreshToken = errors.New("invalid or expired refresh token")
+	ErrRefreshTokenReused  = errors.New("refresh token reuse detected")
+)
+
+// IssueRefreshToken creates a refresh token in a login's token family
+func IssueRefreshToken(userID uint, familyID string) (string, error) {
+	token, err := randomToken(32)
+	if err != nil {
+		return "", fmt.Errorf("failed to generate refresh token: %w", err)
+	}
+
+	row := models.RefreshToken{
+		UserID:    userID,
+		FamilyID:  familyID,
+		TokenHash: hashToken(token),
+		ExpiresAt: time.Now().Add(RefreshTokenTTL),
+	}
+	if err := database.DB.Create(&row).Error; err != nil {
+		return "", fmt.Errorf("failed to store refresh token: %w", err)
+	}
+
+	return token, nil
+}
+
+// RotateRefreshToken consumes a refresh token and issues its successor in the same family.
+// Presenting a token that was already rotated means it leaked, so the whole family is revoked.
+func RotateRefreshToken(token string) (userID uint, familyID string, newToken string, err error) {
+	if token == "" {
+		return 0, "", "", ErrInvalidRefreshToken
+	}
+
+	var row models.RefreshToken
+	if err := database.DB.Where("token_hash = ?", hashToken(token)).First(&row).Error; err != nil {
+		return 0, "", "", ErrInvalidRefreshToken
+	}
+
+	if row.UsedAt != nil || row.RevokedAt != nil {
+		log.Printf("[Auth] Refresh token reuse detected for user %d, revoking family %s", row.UserID, row.FamilyID)
+		if err := RevokeRefreshFamily(row.FamilyID); err != nil {
+			log.Printf("[Auth] Failed to revoke refresh family %s: %v", row.FamilyID, err)
+		}
+		return 0, "", "", ErrRefreshTokenReused
+	}
+
+	if time.Now().After(row.ExpiresAt) {
+		return 0, "", "", ErrInvalidRefreshToken
+	}
+
+	// Mark as used; the condition makes concurrent refreshes race safely
+	now := time.Now()
+	result := database.DB.Model(&models.RefreshToken{}).
+		Where("id = ? AND used_at IS NULL AND revoked_at IS NULL", row.ID).
+		Update("used_at", now)
+	if result.Error != nil {
+		return 0, "", "", fmt.Errorf("failed to rotate refresh token: %w", result.Error)
+	}
+	if result.RowsAffected == 0 {
+		// Someone else rotated it between our read and write
+		if err := RevokeRefreshFamily(row.FamilyID); err != nil {
+			log.Printf("[Auth] Failed to revoke refresh family %s: %v", row.FamilyID, err)
+		}
+		return 0, "", "", ErrRefreshTokenReused
+	}
+
+	newToken, err = IssueRefreshToken(row.UserID, row.FamilyID)
+	if err != nil {
+		return 0, "", "", err
+	}
+
+	return row.UserID, row.FamilyID, newToken, nil
+}
+
+// RevokeRefreshToken revokes the family a refresh token belongs to (used on logout)
+func RevokeRefreshToken(token string) error {
+	var row models.RefreshToken
+	if err := database.DB.Where("token_hash = ?", hashToken(token)).First(&row).Error; err != nil {
+		return ErrInvalidRefreshToken
+	}
+	return RevokeRefreshFamily(row.FamilyID)
+}
+
+// RevokeRefreshFamily revokes every token in a family and the login it belongs to
+func RevokeRefreshFamily(familyID string) error {
+	now := time.Now()
+	// Both or neither, so a login is never left active with its tokens revoked
+	return database.DB.Transaction(func(tx *gorm.DB) error {
+		if err := tx.Model(&models.Login{}).
+			Where("family_id = ? AND revoked_at IS NULL", familyID).
+			Update("revoked_at", now).Error; err != nil {
+			return err
+		}
+		return tx.Model(&models.RefreshToken{}).
+			Where("family_id = ? AND revoked_at IS NULL", familyID).
+			Update("revoked_at", now).Error
+	})
+}
+
+// CleanupExpiredRefreshTokens deletes refresh tokens past their expiry
+func CleanupExpiredRefreshTokens() (int64, error) {
+	result := database.DB.Where("expires_at < ?", time.Now()).Delete(&models.RefreshToken{})
+	return result.RowsAffected, result.Error
+}
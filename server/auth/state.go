@@ -0,0 +1,162 @@
+package auth
+
+import (
+	"crypto/rand"
+	"crypto/sha256"
+	"crypto/subtle"
+	"encoding/base64"
+	"errors"
+	"fmt"
+	"time"
+
+	"re2no/database"
+	"re2no/models"
+
+	"gorm.io/gorm/clause"
+)
+
+// OAuthStateTTL is how long a login attempt may take before its state expires
+const OAuthStateTTL = 10 * time.Minute
+
+// OAuth providers a state can be issued for
+const (
+	ProviderNotion = "notion"
+	ProviderReddit = "reddit"
+)
+
+var (
+	ErrInvalidOAuthState = errors.New("invalid or already used state")
+	ErrExpiredOAuthState = errors.New("state has expired")
+	ErrVerifierMismatch  = errors.New("state was issued to a different browser")
+	ErrUserMismatch      = errors.New("state was issued to a different user")
+)
+
+// IssueOAuthState stores a new single-use state bound to a random verifier.
+// The verifier is handed to the browser (as a cookie) and only its hash is
+// stored, PKCE-style, so a leaked state alone can't complete the login.
+func IssueOAuthState() (state string, verifier string, err error) {
+	state, err = randomToken(32)
+	if err != nil {
+		return "", "", fmt.Errorf("failed to generate state: %w", err)
+	}
+	verifier, err = randomToken(32)
+	if err != nil {
+		return "", "", fmt.Errorf("failed to generate verifier: %w", err)
+	}
+
+	row := models.OAuthState{
+		State:        state,
+		Provider:     ProviderNotion,
+		VerifierHash: hashToken(verifier),
+		ExpiresAt:    time.Now().Add(OAuthStateTTL),
+	}
+	if err := database.DB.Create(&row).Error; err != nil {
+		return "", "", fmt.Errorf("failed to store state: %w", err)
+	}
+
+	return state, verifier, nil
+}
+
+// ConsumeOAuthState atomically deletes the state and checks its expiry and verifier.
+// A state can only ever be consumed once, even if the checks fail.
+func ConsumeOAuthState(state, verifier string) error {
+	if state == "" {
+		return ErrInvalidOAuthState
+	}
+
+	row, err := consumeState(ProviderNotion, state)
+	if err != nil {
+		return err
+	}
+
+	expected := []byte(row.VerifierHash)
+	actual := []byte(hashToken(verifier))
+	if verifier == "" || subtle.ConstantTimeCompare(expected, actual) != 1 {
+		return ErrVerifierMismatch
+	}
+
+	return nil
+}
+
+// IssueAccountLinkState stores a single-use state for linking a provider
+// account to an already signed-in user. The callback is completed by the
+// frontend with the user's own credentials, so the state is bound to the user
+// instead of a browser cookie.
+func IssueAccountLinkState(provider string, userID uint) (string, error) {
+	state, err := randomToken(32)
+	if err != nil {
+		return "", fmt.Errorf("failed to generate state: %w", err)
+	}
+
+	row := models.OAuthState{
+		State:     state,
+		Provider:  provider,
+		UserID:    &userID,
+		ExpiresAt: time.Now().Add(OAuthStateTTL),
+	}
+	if err := database.DB.Create(&row).Error; err != nil {
+		return "", fmt.Errorf("failed to store state: %w", err)
+	}
+
+	return state, nil
+}
+
+// ConsumeAccountLinkState atomically deletes an account link state and checks
+// that it was issued to userID
+func ConsumeAccountLinkState(provider, state string, userID uint) error {
+	if state == "" {
+		return ErrInvalidOAuthState
+	}
+
+	row, err := consumeState(provider, state)
+	if err != nil {
+		return err
+	}
+
+	if row.UserID == nil || *row.UserID != userID {
+		return ErrUserMismatch
+	}
+
+	return nil
+}
+
+// consumeState deletes a provider's state and returns it if it hadn't expired
+func consumeState(provider, state string) (*models.OAuthState, error) {
+	var row models.OAuthState
+	result := database.DB.Clauses(clause.Returning{}).
+		Where("state = ? AND provider = ?", state, provider).
+		Delete(&row)
+	if result.Error != nil {
+		return nil, fmt.Errorf("failed to consume state: %w", result.Error)
+	}
+	if result.RowsAffected == 0 {
+		return nil, ErrInvalidOAuthState
+	}
+
+	if time.Now().After(row.ExpiresAt) {
+		return nil, ErrExpiredOAuthState
+	}
+
+	return &row, nil
+}
+
+// CleanupExpiredOAuthStates deletes states from abandoned login attempts
+func CleanupExpiredOAuthStates() (int64, error) {
+	result := database.DB.Where("expires_at < ?", time.Now()).Delete(&models.OAuthState{})
+	return result.RowsAffected, result.Error
+}
+
+// hashToken returns the SHA-256 of a token, as used for PKCE-style challenges and stored secrets
+func hashToken(token string) string {
+	sum := sha256.Sum256([]byte(token))
+	return base64.RawURLEncoding.EncodeToString(sum[:])
+}
+
+// randomToken returns n random bytes encoded as URL-safe base64
+func randomToken(n int) (string, error) {
+	b := make([]byte, n)
+	if _, err := rand.Read(b); err != nil {
+		return "", err
+	}
+	return base64.RawURLEncoding.EncodeToString(b), nil
+}
@@ -0,0 +1,128 @@
+// Command reencrypt encrypts legacy plaintext tokens and re-wraps tokens sealed
+// with a retired key so that every stored secret uses the active encryption key.
+//
+// Usage:
+//
+//	go run ./cmd/reencrypt [-dry-run] [-batch 100]
+package main
+
+import (
+	"flag"
+	"fmt"
+	"log"
+
+	"re2no/database"
+	"re2no/encryption"
+
+	"github.com/joho/godotenv"
+)
+
+// encryptedColumns lists every table column written through the "encrypted" serializer
+var encryptedColumns = map[string][]string{
+	"sessions":           {"access_token", "refresh_token"},
+	"notion_connections": {"access_token"},
+	"reddit_connections": {"access_token", "refresh_token"},
+}
+
+func main() {
+	dryRun := flag.Bool("dry-run", false, "report rows that need re-encryption without writing")
+	batchSize := flag.Int("batch", 100, "rows to process per batch")
+	flag.Parse()
+
+	if err := godotenv.Load(); err != nil {
+		log.Println("Warning: .env file not found, using environment variables")
+	}
+
+	encryption.Init()
+
+	if err := database.Connect(); err != nil {
+		log.Fatalf("Failed to connect to database: %v", err)
+	}
+	defer database.Close()
+
+	for table, columns := range encryptedColumns {
+		updated, err := reencryptTable(table, columns, *batchSize, *dryRun)
+		if err != nil {
+			log.Fatalf("Failed to re-encrypt %s: %v", table, err)
+		}
+		log.Printf("%s: %d rows re-encrypted (dry run: %v)", table, updated, *dryRun)
+	}
+}
+
+// reencryptTable rewrites every row whose columns are plaintext or use a retired key
+func reencryptTable(table string, columns []string, batchSize int, dryRun bool) (int, error) {
+	selectColumns := append([]string{"id"}, columns...)
+	updated := 0
+	lastID := uint(0)
+
+	for {
+		var rows []map[string]interface{}
+		err := database.DB.Table(table).
+			Select(selectColumns).
+			Where("id > ?", lastID).
+			Order("id").
+			Limit(batchSize).
+			Find(&rows).Error
+		if err != nil {
+			return updated, err
+		}
+		if len(rows) == 0 {
+			return updated, nil
+		}
+
+		for _, row := range rows {
+			id, err := rowID(row["id"])
+			if err != nil {
+				return updated, err
+			}
+			lastID = id
+
+			changes := map[string]interface{}{}
+			for _, column := range columns {
+				value, _ := row[column].(string)
+				if !encryption.NeedsRotation(value) {
+					continue
+				}
+
+				plaintext, err := encryption.Decrypt(value)
+				if err != nil {
+					return updated, err
+				}
+				ciphertext, err := encryption.Encrypt(plaintext)
+				if err != nil {
+					return updated, err
+				}
+				changes[column] = ciphertext
+			}
+
+			if len(changes) == 0 {
+				continue
+			}
+
+			updated++
+			if dryRun {
+				log.Printf("%s #%d needs re-encryption", table, id)
+				continue
+			}
+
+			// Write raw ciphertext; going through a model would encrypt twice
+			if err := database.DB.Table(table).Where("id = ?", id).UpdateColumns(changes).Error; err != nil {
+				return updated, err
+			}
+		}
+	}
+}
+
+// rowID converts the driver's integer type for the id column
+func rowID(value interface{}) (uint, error) {
+	switch v := value.(type) {
+	case int64:
+		return uint(v), nil
+	case int32:
+		return uint(v), nil
+	case uint:
+		return v, nil
+	default:
+		return 0, fmt.Errorf("unexpected id type %T", value)
+	}
+}
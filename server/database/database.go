@@ -46,17 +46,66 @@ func Migrate() error {
 	err := DB.AutoMigrate(
 		&models.User{},
 		&models.Session{},
+		&models.NotionConnection{},
+		&models.RedditConnection{},
 		&models.RedditPost{},
 		&models.OAuthState{},
+		&models.AuthCode{},
+		&models.RefreshToken{},
+		&models.Login{},
+		&models.RevokedToken{},
+		&models.APIToken{},
+		&models.DatabaseMapping{},
+		&models.Job{},
+		&models.JobItem{},
+		&models.JobEvent{},
+		&models.Subscription{},
+		&models.SubscriptionRun{},
 	)
 	if err != nil {
 		return fmt.Errorf("failed to migrate database: %w", err)
 	}
 
+	// Saved posts used to be unique by reddit_id alone, which blocked every
+	// other user from saving the same post; idx_reddit_post_destination replaces it
+	if DB.Migrator().HasIndex(&models.RedditPost{}, "idx_reddit_posts_reddit_id") {
+		if err := DB.Migrator().DropIndex(&models.RedditPost{}, "idx_reddit_posts_reddit_id"); err != nil {
+			return fmt.Errorf("failed to drop legacy reddit_id index: %w", err)
+		}
+	}
+
+	if err := migrateSessionsToConnections(); err != nil {
+		return fmt.Errorf("failed to migrate sessions to notion connections: %w", err)
+	}
+
 	log.Println("Database migration completed successfully")
 	return nil
 }
 
+// migrateSessionsToConnections copies each user's latest legacy session into a
+// Notion connection for the workspace stored on the user. Users that already
+// have a connection are skipped, so this is safe to run on every start.
+// The token column is copied as-is, so it stays encrypted.
+func migrateSessionsToConnections() error {
+	result := DB.Exec(`
+		INSERT INTO notion_connections (user_id, workspace_id, workspace_name, bot_id, access_token, token_type, created_at, updated_at)
+		SELECT DISTINCT ON (s.user_id)
+			s.user_id, COALESCE(NULLIF(u.workspace_id, ''), u.bot_id), u.workspace_name, u.bot_id,
+			s.access_token, s.token_type, s.created_at, s.updated_at
+		FROM sessions s
+		JOIN users u ON u.id = s.user_id
+		WHERE NOT EXISTS (SELECT 1 FROM notion_connections nc WHERE nc.user_id = s.user_id)
+		ORDER BY s.user_id, s.expires_at DESC
+		ON CONFLICT DO NOTHING`)
+	if result.Error != nil {
+		return result.Error
+	}
+	if result.RowsAffected > 0 {
+		log.Printf("Migrated %d legacy sessions to notion connections", result.RowsAffected)
+	}
+	return nil
+}
+
 // Close closes the database connection
 func Close() error {
 	sqlDB, err := DB.DB()
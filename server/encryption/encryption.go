@@ -0,0 +1,209 @@
+package encryption
+
+import (
+	"crypto/aes"
+	"crypto/cipher"
+	"crypto/rand"
+	"encoding/base64"
+	"errors"
+	"fmt"
+	"io"
+	"os"
+	"strings"
+)
+
+// Encrypted values are stored as "enc:v1:<key id>:<wrapped data key>:<ciphertext>".
+// Every value gets its own random data key, which is sealed with the master key
+// identified by <key id>; rotating master keys only requires re-wrapping.
+const (
+	envelopePrefix  = "enc:v1:"
+	dataKeySize     = 32
+	masterKeyLength = 32
+)
+
+var (
+	masterKeys  map[string][]byte
+	activeKeyID string
+)
+
+// Init loads the master keys from the environment.
+//
+// TOKEN_ENCRYPTION_KEYS is a comma-separated list of "<key id>:<base64 32-byte key>".
+// TOKEN_ENCRYPTION_KEY_ID selects the key used for new values (defaults to the last one listed).
+func Init() {
+	keys, active, err := parseKeys(os.Getenv("TOKEN_ENCRYPTION_KEYS"), os.Getenv("TOKEN_ENCRYPTION_KEY_ID"))
+	if err != nil {
+		panic("invalid token encryption configuration: " + err.Error())
+	}
+	masterKeys = keys
+	activeKeyID = active
+}
+
+// parseKeys parses the key list and resolves the active key ID
+func parseKeys(spec, active string) (map[string][]byte, string, error) {
+	if strings.TrimSpace(spec) == "" {
+		return nil, "", errors.New("TOKEN_ENCRYPTION_KEYS environment variable is required")
+	}
+
+	keys := make(map[string][]byte)
+	last := ""
+	for _, entry := range strings.Split(spec, ",") {
+		entry = strings.TrimSpace(entry)
+		if entry == "" {
+			continue
+		}
+
+		id, encoded, ok := strings.Cut(entry, ":")
+		if !ok || id == "" || strings.ContainsAny(id, ": ") {
+			return nil, "", fmt.Errorf("key entry %q must be in the form <id>:<base64 key>", entry)
+		}
+
+		key, err := base64.StdEncoding.DecodeString(encoded)
+		if err != nil {
+			return nil, "", fmt.Errorf("key %q is not valid base64: %w", id, err)
+		}
+		if len(key) != masterKeyLength {
+			return nil, "", fmt.Errorf("key %q must be %d bytes, got %d", id, masterKeyLength, len(key))
+		}
+
+		keys[id] = key
+		last = id
+	}
+
+	if active == "" {
+		active = last
+	}
+	if _, ok := keys[active]; !ok {
+		return nil, "", fmt.Errorf("active key %q is not in TOKEN_ENCRYPTION_KEYS", active)
+	}
+
+	return keys, active, nil
+}
+
+// Encrypt seals plaintext with a fresh data key wrapped by the active master key
+func Encrypt(plaintext string) (string, error) {
+	if activeKeyID == "" {
+		return "", errors.New("encryption keys are not initialized")
+	}
+
+	dataKey := make([]byte, dataKeySize)
+	if _, err := io.ReadFull(rand.Reader, dataKey); err != nil {
+		return "", fmt.Errorf("failed to generate data key: %w", err)
+	}
+
+	// Bind both layers to the key ID so it can't be swapped out
+	aad := []byte(activeKeyID)
+
+	wrappedKey, err := seal(masterKeys[activeKeyID], dataKey, aad)
+	if err != nil {
+		return "", fmt.Errorf("failed to wrap data key: %w", err)
+	}
+
+	ciphertext, err := seal(dataKey, []byte(plaintext), aad)
+	if err != nil {
+		return "", fmt.Errorf("failed to encrypt value: %w", err)
+	}
+
+	return envelopePrefix + activeKeyID + ":" +
+		base64.RawURLEncoding.EncodeToString(wrappedKey) + ":" +
+		base64.RawURLEncoding.EncodeToString(ciphertext), nil
+}
+
+// Decrypt opens a value produced by Encrypt. Values without the envelope
+// prefix are treated as legacy plaintext and returned unchanged.
+func Decrypt(value string) (string, error) {
+	if !IsEncrypted(value) {
+		return value, nil
+	}
+
+	parts := strings.Split(strings.TrimPrefix(value, envelopePrefix), ":")
+	if len(parts) != 3 {
+		return "", errors.New("malformed encrypted value")
+	}
+	keyID := parts[0]
+
+	masterKey, ok := masterKeys[keyID]
+	if !ok {
+		return "", fmt.Errorf("unknown encryption key %q", keyID)
+	}
+
+	wrappedKey, err := base64.RawURLEncoding.DecodeString(parts[1])
+	if err != nil {
+		return "", fmt.Errorf("malformed data key: %w", err)
+	}
+	ciphertext, err := base64.RawURLEncoding.DecodeString(parts[2])
+	if err != nil {
+		return "", fmt.Errorf("malformed ciphertext: %w", err)
+	}
+
+	aad := []byte(keyID)
+
+	dataKey, err := open(masterKey, wrappedKey, aad)
+	if err != nil {
+		return "", fmt.Errorf("failed to unwrap data key: %w", err)
+	}
+
+	plaintext, err := open(dataKey, ciphertext, aad)
+	if err != nil {
+		return "", fmt.Errorf("failed to decrypt value: %w", err)
+	}
+
+	return string(plaintext), nil
+}
+
+// IsEncrypted reports whether value is an encryption envelope
+func IsEncrypted(value string) bool {
+	return strings.HasPrefix(value, envelopePrefix)
+}
+
+// KeyID returns the master key ID a value was encrypted with, or "" for plaintext
+func KeyID(value string) string {
+	if !IsEncrypted(value) {
+		return ""
+	}
+	keyID, _, _ := strings.Cut(strings.TrimPrefix(value, envelopePrefix), ":")
+	return keyID
+}
+
+// NeedsRotation reports whether a stored value is plaintext or sealed with a non-active key
+func NeedsRotation(value string) bool {
+	return value != "" && KeyID(value) != activeKeyID
+}
+
+// seal encrypts data with AES-GCM, prefixing the random nonce
+func seal(key, data, aad []byte) ([]byte, error) {
+	gcm, err := newGCM(key)
+	if err != nil {
+		return nil, err
+	}
+
+	nonce := make([]byte, gcm.NonceSize())
+	if _, err := io.ReadFull(rand.Reader, nonce); err != nil {
+		return nil, err
+	}
+
+	return gcm.Seal(nonce, nonce, data, aad), nil
+}
+
+// open decrypts data produced by seal
+func open(key, data, aad []byte) ([]byte, error) {
+	gcm, err := newGCM(key)
+	if err != nil {
+		return nil, err
+	}
+
+	if len(data) < gcm.NonceSize() {
+		return nil, errors.New("ciphertext too short")
+	}
+
+	nonce, ciphertext := data[:gcm.NonceSize()], data[gcm.NonceSize():]
+	return gcm.Open(nil, nonce, ciphertext, aad)
+}
+
+func newGCM(key []byte) (cipher.AEAD, error) {
+	block, err := aes.NewCipher(key)
+	if err != nil {
+		return nil, err
+	}
+	return cipher.NewGCM(block)
+}
@@ -0,0 +1,235 @@
+package encryption
+
+import (
+	"context"
+	"encoding/base64"
+	"reflect"
+	"strings"
+	"sync"
+	"testing"
+
+	"gorm.io/gorm/schema"
+)
+
+// useKeys installs master keys for one test from a TOKEN_ENCRYPTION_KEYS spec
+func useKeys(t *testing.T, spec, active string) {
+	t.Helper()
+
+	keys, activeID, err := parseKeys(spec, active)
+	if err != nil {
+		t.Fatal(err)
+	}
+	prevKeys, prevActive := masterKeys, activeKeyID
+	masterKeys, activeKeyID = keys, activeID
+	t.Cleanup(func() { masterKeys, activeKeyID = prevKeys, prevActive })
+}
+
+func testKey(b byte) string {
+	return base64.StdEncoding.EncodeToString([]byte(strings.Repeat(string(b), masterKeyLength)))
+}
+
+func TestEncryptRoundTrip(t *testing.T) {
+	useKeys(t, "old:"+testKey('a')+",new:"+testKey('b'), "")
+
+	for _, plaintext := range []string{"", "token", "secret_ünïcode:with:colons"} {
+		encrypted, err := Encrypt(plaintext)
+		if err != nil {
+			t.Fatal(err)
+		}
+		if !IsEncrypted(encrypted) || KeyID(encrypted) != "new" {
+			t.Errorf("Encrypt(%q) = %q, want an envelope sealed with the last key", plaintext, encrypted)
+		}
+		if plaintext != "" && strings.Contains(encrypted, plaintext) {
+			t.Errorf("Encrypt(%q) = %q leaks the plaintext", plaintext, encrypted)
+		}
+
+		decrypted, err := Decrypt(encrypted)
+		if err != nil {
+			t.Fatalf("Decrypt(%q): %v", encrypted, err)
+		}
+		if decrypted != plaintext {
+			t.Errorf("Decrypt(Encrypt(%q)) = %q", plaintext, decrypted)
+		}
+	}
+
+	first, _ := Encrypt("token")
+	second, _ := Encrypt("token")
+	if first == second {
+		t.Error("encrypting the same value twice gave the same envelope")
+	}
+}
+
+func TestDecryptWithRotatedKeys(t *testing.T) {
+	useKeys(t, "old:"+testKey('a'), "")
+	encrypted, err := Encrypt("token")
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	// The old key is still listed, so existing values keep working
+	useKeys(t, "old:"+testKey('a')+",new:"+testKey('b'), "")
+	if decrypted, err := Decrypt(encrypted); err != nil || decrypted != "token" {
+		t.Errorf("Decrypt() after rotation = %q, %v; want token", decrypted, err)
+	}
+	if !NeedsRotation(encrypted) {
+		t.Error("NeedsRotation() = false for a value sealed with the old key")
+	}
+}
+
+func TestDecryptFailures(t *testing.T) {
+	useKeys(t, "k1:"+testKey('a'), "")
+	encrypted, err := Encrypt("token")
+	if err != nil {
+		t.Fatal(err)
+	}
+	parts := strings.Split(strings.TrimPrefix(encrypted, envelopePrefix), ":")
+
+	// flip changes the first character of a base64 part
+	flip := func(part string) string {
+		if part[0] == 'A' {
+			return "B" + part[1:]
+		}
+		return "A" + part[1:]
+	}
+
+	tests := []struct {
+		name  string
+		value string
+		keys  string
+	}{
+		{"wrong key", encrypted, "k1:" + testKey('b')},
+		{"unknown key", encrypted, "k2:" + testKey('a')},
+		{"tampered ciphertext", envelopePrefix + parts[0] + ":" + parts[1] + ":" + flip(parts[2]), ""},
+		{"tampered data key", envelopePrefix + parts[0] + ":" + flip(parts[1]) + ":" + parts[2], ""},
+		{"swapped key id", envelopePrefix + "k2:" + parts[1] + ":" + parts[2], "k1:" + testKey('a') + ",k2:" + testKey('a')},
+		{"truncated", encrypted[:len(encrypted)-8], ""},
+		{"missing part", envelopePrefix + parts[0] + ":" + parts[1], ""},
+		{"not base64", envelopePrefix + parts[0] + ":" + parts[1] + ":!!!", ""},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if tt.keys != "" {
+				useKeys(t, tt.keys, "")
+			}
+			if decrypted, err := Decrypt(tt.value); err == nil {
+				t.Errorf("Decrypt(%q) = %q, want an error", tt.value, decrypted)
+			}
+		})
+	}
+}
+
+func TestDecryptLegacyPlaintext(t *testing.T) {
+	useKeys(t, "k1:"+testKey('a'), "")
+
+	for _, value := range []string{"", "legacy-token", "enc:v0:looks-close"} {
+		decrypted, err := Decrypt(value)
+		if err != nil || decrypted != value {
+			t.Errorf("Decrypt(%q) = %q, %v; want it unchanged", value, decrypted, err)
+		}
+	}
+	if !NeedsRotation("legacy-token") {
+		t.Error("NeedsRotation() = false for a plaintext value")
+	}
+	if NeedsRotation("") {
+		t.Error("NeedsRotation() = true for an empty value")
+	}
+}
+
+func TestParseKeys(t *testing.T) {
+	valid := "a:" + testKey('a')
+
+	tests := []struct {
+		name    string
+		spec    string
+		active  string
+		wantErr bool
+	}{
+		{"single key", valid, "", false},
+		{"explicit active key", valid + ", b:" + testKey('b'), "a", false},
+		{"empty", " ", "", true},
+		{"missing id", ":" + testKey('a'), "", true},
+		{"not base64", "a:not-base64!", "", true},
+		{"short key", "a:" + base64.StdEncoding.EncodeToString([]byte("short")), "", true},
+		{"unknown active key", valid, "b", true},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			_, _, err := parseKeys(tt.spec, tt.active)
+			if (err != nil) != tt.wantErr {
+				t.Errorf("parseKeys(%q, %q) error = %v, wantErr %v", tt.spec, tt.active, err, tt.wantErr)
+			}
+		})
+	}
+}
+
+type tokenRecord struct {
+	ID    uint
+	Token string `gorm:"serializer:encrypted"`
+}
+
+func TestSerializer(t *testing.T) {
+	useKeys(t, "k1:"+testKey('a'), "")
+
+	s, err := schema.Parse(&tokenRecord{}, &sync.Map{}, schema.NamingStrategy{})
+	if err != nil {
+		t.Fatal(err)
+	}
+	field := s.LookUpField("Token")
+	ctx := context.Background()
+
+	t.Run("round trip", func(t *testing.T) {
+		record := tokenRecord{Token: "secret"}
+		stored, err := field.Serializer.Value(ctx, field, reflect.ValueOf(&record).Elem(), record.Token)
+		if err != nil {
+			t.Fatal(err)
+		}
+		if value, _ := stored.(string); !IsEncrypted(value) {
+			t.Fatalf("Value() = %v, want an encrypted envelope", stored)
+		}
+
+		var loaded tokenRecord
+		if err := field.Serializer.Scan(ctx, field, reflect.ValueOf(&loaded).Elem(), stored); err != nil {
+			t.Fatal(err)
+		}
+		if loaded.Token != "secret" {
+			t.Errorf("Scan() = %q, want secret", loaded.Token)
+		}
+	})
+
+	t.Run("empty stays empty", func(t *testing.T) {
+		var record tokenRecord
+		stored, err := field.Serializer.Value(ctx, field, reflect.ValueOf(&record).Elem(), "")
+		if err != nil || stored != "" {
+			t.Errorf("Value(\"\") = %v, %v; want an empty string", stored, err)
+		}
+	})
+
+	t.Run("legacy plaintext and null", func(t *testing.T) {
+		for _, stored := range []interface{}{"legacy-token", []byte("legacy-token"), nil} {
+			loaded := tokenRecord{Token: "stale"}
+			if err := field.Serializer.Scan(ctx, field, reflect.ValueOf(&loaded).Elem(), stored); err != nil {
+				t.Fatalf("Scan(%v): %v", stored, err)
+			}
+			want := "legacy-token"
+			if stored == nil {
+				want = ""
+			}
+			if loaded.Token != want {
+				t.Errorf("Scan(%v) = %q, want %q", stored, loaded.Token, want)
+			}
+		}
+	})
+
+	t.Run("tampered value", func(t *testing.T) {
+		stored, err := Encrypt("secret")
+		if err != nil {
+			t.Fatal(err)
+		}
+		var loaded tokenRecord
+		if err := field.Serializer.Scan(ctx, field, reflect.ValueOf(&loaded).Elem(), stored[:len(stored)-4]); err == nil {
+			t.Error("Scan() of a tampered value succeeded")
+		}
+	})
+}
@@ -2,33 +2,75 @@ package handlers
 
 import (
 	"encoding/json"
+	"errors"
 	"log"
 	"net/http"
+	"net/url"
 	"os"
 	"re2no/auth"
 	"re2no/database"
 	"re2no/models"
-	"time"
+	"strconv"
+	"strings"
 
 	"github.com/gin-gonic/gin"
-	"github.com/google/uuid"
 	"golang.org/x/oauth2"
+	"gorm.io/gorm"
 )
 
-// Store for OAuth state tokens (in production, use Redis or similar)
-var stateStore = make(map[string]bool)
+const (
+	// oauthVerifierCookie holds the verifier the OAuth state is bound to
+	oauthVerifierCookie = "oauth_verifier"
 
-// HandleNotionLogin initiates the Notion OAuth flow
+	accessTokenCookie  = "auth_token"
+	refreshTokenCookie = "refresh_token"
+	refreshCookiePath  = "/api/auth" // refresh tokens are only ever sent to auth routes
+)
+
+// HandleNotionLogin initiates the Notion OAuth flow.
+// With ?redirect=true the browser is sent straight to Notion, which lets the
+// verifier cookie be set first-party even when the frontend is on another domain.
 func HandleNotionLogin(c *gin.Context) {
-	state := uuid.New().String()
-	stateStore[state] = true
+	state, verifier, err := auth.IssueOAuthState()
+	if err != nil {
+		log.Printf("ERROR: Failed to issue OAuth state: %v", err)
+		c.JSON(http.StatusInternalServerError, gin.H{
+			"error": "failed to start login",
+		})
+		return
+	}
+
+	setVerifierCookie(c, verifier, int(auth.OAuthStateTTL.Seconds()))
+
+	authURL := auth.NotionOAuthConfig.AuthCodeURL(state, oauth2.AccessTypeOffline)
+	if c.Query("redirect") == "true" {
+		c.Redirect(http.StatusTemporaryRedirect, authURL)
+		return
+	}
 
-	url := auth.NotionOAuthConfig.AuthCodeURL(state, oauth2.AccessTypeOffline)
 	c.JSON(http.StatusOK, gin.H{
-		"url": url,
+		"url": authURL,
 	})
 }
 
+// setVerifierCookie sets (or clears, with a negative maxAge) the OAuth verifier cookie.
+// SameSite=Lax is enough because Notion sends the browser back with a top-level GET.
+func setVerifierCookie(c *gin.Context, verifier string, maxAge int) {
+	frontendURL := os.Getenv("FRONTEND_URL")
+	isProduction := frontendURL != "" && frontendURL != "http://localhost:5173"
+
+	c.SetSameSite(http.SameSiteLaxMode)
+	c.SetCookie(
+		oauthVerifierCookie,
+		verifier,
+		maxAge,
+		"/api/auth/notion",
+		"",
+		isProduction, // secure
+		true,         // httpOnly
+	)
+}
+
 // HandleNotionCallback handles the OAuth callback from Notion
 func HandleNotionCallback(c *gin.Context) {
 	log.Println("=== OAuth Callback Started ===")
@@ -37,7 +79,7 @@ func HandleNotionCallback(c *gin.Context) {
 	code := c.Query("code")
 	errorParam := c.Query("error")
 
-	log.Printf("State: %s, Code: %s", state, code[:20]+"...")
+	log.Printf("Callback received (has code: %v)", code != "")
 
 	// Check for OAuth errors
 	if errorParam != "" {
@@ -48,15 +90,16 @@ func HandleNotionCallback(c *gin.Context) {
 		return
 	}
 
-	// Validate state
-	if !stateStore[state] {
-		log.Printf("ERROR: Invalid state parameter")
+	// Validate state (single use, bound to this browser's verifier cookie)
+	verifier, _ := c.Cookie(oauthVerifierCookie)
+	setVerifierCookie(c, "", -1)
+	if err := auth.ConsumeOAuthState(state, verifier); err != nil {
+		log.Printf("ERROR: Invalid state parameter: %v", err)
 		c.JSON(http.StatusBadRequest, gin.H{
 			"error": "invalid state parameter",
 		})
 		return
 	}
-	delete(stateStore, state)
 	log.Println("State validated successfully")
 
 	// Exchange code for token and get user info
@@ -109,8 +152,7 @@ func HandleNotionCallback(c *gin.Context) {
 	// If still empty, log the full response for debugging
 	if notionUserID == "" {
 		log.Printf("ERROR: Could not extract user ID from Notion response")
-		log.Printf("Full NotionUser: AccessToken=%s, BotID=%s, WorkspaceID=%s, WorkspaceName=%s",
-			notionUser.AccessToken[:20]+"...", notionUser.BotID, notionUser.WorkspaceID, notionUser.WorkspaceName)
+		log.Printf("NotionUser: BotID=%s, WorkspaceID=%s", notionUser.BotID, notionUser.WorkspaceID)
 		c.JSON(http.StatusInternalServerError, gin.H{
 			"error": "failed to extract user information from Notion response",
 		})
@@ -149,256 +191,412 @@ func HandleNotionCallback(c *gin.Context) {
 		log.Printf("User found with ID: %d", user.ID)
 	}
 
-	// Create or update session
-	log.Println("Managing session...")
-	var session models.Session
-	sessionResult := database.DB.Where("user_id = ?", user.ID).First(&session)
-
-	expiresAt := time.Now().Add(30 * 24 * time.Hour) // 30 days
-
-	if sessionResult.Error != nil {
-		log.Println("Session not found, creating new session...")
-		// Create new session
-		session = models.Session{
-			UserID:      user.ID,
-			AccessToken: notionUser.AccessToken,
-			TokenType:   "Bearer",
-			ExpiresAt:   expiresAt,
-		}
-
-		if err := database.DB.Create(&session).Error; err != nil {
-			log.Printf("ERROR: Failed to create session: %v", err)
+	// Create or update the connection for this workspace; other workspaces the
+	// user connected keep their own tokens
+	log.Printf("Managing connection for workspace %s...", notionUser.WorkspaceID)
+	var connection models.NotionConnection
+	connectionResult := database.DB.Where("user_id = ? AND workspace_id = ?", user.ID, notionUser.WorkspaceID).First(&connection)
+
+	connection.UserID = user.ID
+	connection.WorkspaceID = notionUser.WorkspaceID
+	connection.WorkspaceName = notionUser.WorkspaceName
+	connection.WorkspaceIcon = notionUser.WorkspaceIcon
+	connection.BotID = notionUser.BotID
+	connection.AccessToken = notionUser.AccessToken
+	connection.TokenType = notionUser.TokenType
+
+	if connectionResult.Error != nil {
+		log.Println("Connection not found, creating new connection...")
+		if err := database.DB.Create(&connection).Error; err != nil {
+			log.Printf("ERROR: Failed to create connection: %v", err)
 			c.JSON(http.StatusInternalServerError, gin.H{
-				"error": "failed to create session: " + err.Error(),
+				"error": "failed to save workspace connection",
 			})
 			return
 		}
-		log.Println("Session created successfully")
+		log.Println("Connection created successfully")
 	} else {
-		log.Println("Session found, updating...")
-		// Update existing session
-		session.AccessToken = notionUser.AccessToken
-		session.ExpiresAt = expiresAt
-		database.DB.Save(&session)
-		log.Println("Session updated successfully")
+		log.Println("Connection found, updating...")
+		if err := database.DB.Save(&connection).Error; err != nil {
+			log.Printf("ERROR: Failed to update connection: %v", err)
+			c.JSON(http.StatusInternalServerError, gin.H{
+				"error": "failed to save workspace connection",
+			})
+			return
+		}
+		log.Println("Connection updated successfully")
 	}
 
-	// Generate JWT token
-	log.Println("Generating JWT token...")
-	token, err := auth.GenerateToken(user.ID, user.Email)
+	// Issue a one-time login code; the frontend redeems it for a JWT so the
+	// token never appears in a URL, browser history, referrer or access log
+	loginCode, err := auth.IssueAuthCode(user.ID)
 	if err != nil {
-		log.Printf("ERROR: Failed to generate token: %v", err)
+		log.Printf("ERROR: Failed to issue login code: %v", err)
 		c.JSON(http.StatusInternalServerError, gin.H{
-			"error": "failed to generate token: " + err.Error(),
+			"error": "failed to complete login",
 		})
 		return
 	}
-	log.Println("JWT token generated successfully")
-
-	// Set HTTP-only cookie
-	// Note: SameSite=None requires Secure=true, so we always set it for production
-	frontendURL := os.Getenv("FRONTEND_URL")
-	isProduction := frontendURL != "" && frontendURL != "http://localhost:5173"
 
-	if isProduction {
-		// Production: Secure=true, SameSite=None for cross-origin
-		c.SetSameSite(http.SameSiteNoneMode)
-		c.SetCookie(
-			"auth_token",
-			token,
-			int(24*time.Hour.Seconds()),
-			"/",
-			"",
-			true, // secure: must be true for SameSite=None
-			true, // httpOnly
-		)
-	} else {
-		// Development: Secure=false, SameSite=Lax
-		c.SetSameSite(http.SameSiteLaxMode)
-		c.SetCookie(
-			"auth_token",
-			token,
-			int(24*time.Hour.Seconds()),
-			"/",
-			"",
-			false, // secure: false for local HTTP
-			true,  // httpOnly
-		)
-	}
-	log.Printf("Auth cookie set (secure=%v, sameSite=%s)", isProduction, map[bool]string{true: "None", false: "Lax"}[isProduction])
-
-	// Redirect to frontend dashboard with token in URL
-	// Note: Cookie won't persist across redirect domains, so we pass token in URL
-	// Frontend will make an API call to exchange token for a proper cookie
-	redirectURL := frontendURL
+	redirectURL := os.Getenv("FRONTEND_URL")
 	if redirectURL == "" {
 		redirectURL = "http://localhost:5173"
 	}
 
-	log.Printf("Redirecting to: %s/dashboard?auth=success&token=%s", redirectURL, token)
+	log.Printf("Redirecting to: %s/dashboard?auth=success", redirectURL)
 	log.Println("=== OAuth Callback Completed Successfully ===")
 
-	c.Redirect(http.StatusTemporaryRedirect, redirectURL+"/dashboard?auth=success&token="+token)
+	c.Redirect(http.StatusTemporaryRedirect, redirectURL+"/dashboard?auth=success&code="+url.QueryEscape(loginCode))
 }
 
-// HandleGetUser returns the current authenticated user
+// HandleGetUser returns the current authenticated user and their connected workspaces
 func HandleGetUser(c *gin.Context) {
-	// Get token from Authorization header (for cross-domain)
-	authHeader := c.GetHeader("Authorization")
-	var tokenString string
-
-	if authHeader != "" && len(authHeader) > 7 && authHeader[:7] == "Bearer " {
-		tokenString = authHeader[7:]
-	} else {
-		// Fallback: Try to get token from cookie (for same-domain/local dev)
-		var err error
-		tokenString, err = c.Cookie("auth_token")
-		if err != nil {
-			c.JSON(http.StatusUnauthorized, gin.H{
-				"error": "not authenticated",
-			})
-			return
-		}
-	}
-
-	// Validate token
-	claims, err := auth.ValidateToken(tokenString)
-	if err != nil {
+	userInterface, exists := c.Get("user")
+	if !exists {
 		c.JSON(http.StatusUnauthorized, gin.H{
-			"error": "invalid token",
+			"error": "authentication required",
 		})
 		return
 	}
+	user := userInterface.(*models.User)
 
-	// Get user from database
-	var user models.User
-	if err := database.DB.First(&user, claims.UserID).Error; err != nil {
-		c.JSON(http.StatusNotFound, gin.H{
-			"error": "user not found",
+	var connections []models.NotionConnection
+	if err := database.DB.Where("user_id = ?", user.ID).Order("updated_at DESC").Find(&connections).Error; err != nil {
+		log.Printf("ERROR: Failed to list workspaces: %v", err)
+		c.JSON(http.StatusInternalServerError, gin.H{
+			"error": "failed to load workspaces",
 		})
 		return
 	}
 
 	c.JSON(http.StatusOK, gin.H{
-		"user": user,
+		"user":       user,
+		"workspaces": connections,
 	})
 }
 
-// HandleExchangeToken exchanges a JWT token from URL for an HTTP-only cookie
+// HandleExchangeToken redeems the one-time login code from the OAuth redirect
+// for a JWT, returned in the body and set as an HTTP-only cookie
 func HandleExchangeToken(c *gin.Context) {
-	// Get token from request body
+	// Get code from request body
 	var req struct {
-		Token string `json:"token" binding:"required"`
+		Code string `json:"code" binding:"required"`
 	}
 
 	if err := c.ShouldBindJSON(&req); err != nil {
 		c.JSON(http.StatusBadRequest, gin.H{
-			"error": "token is required",
+			"error": "code is required",
 		})
 		return
 	}
 
-	// Validate token
-	claims, err := auth.ValidateToken(req.Token)
+	// Redeem code (single use)
+	userID, err := auth.RedeemAuthCode(req.Code)
 	if err != nil {
+		log.Printf("Login code rejected: %v", err)
 		c.JSON(http.StatusUnauthorized, gin.H{
-			"error": "invalid token",
+			"error": "invalid or expired code",
 		})
 		return
 	}
 
 	// Verify user exists
 	var user models.User
-	if err := database.DB.First(&user, claims.UserID).Error; err != nil {
+	if err := database.DB.First(&user, userID).Error; err != nil {
 		c.JSON(http.StatusNotFound, gin.H{
 			"error": "user not found",
 		})
 		return
 	}
 
-	// Set cookie with the validated token
+	// Record the new login and issue its access and refresh tokens
+	login, err := auth.CreateLogin(user.ID, c.Request.UserAgent(), c.ClientIP())
+	if err != nil {
+		log.Printf("ERROR: Failed to record login: %v", err)
+		c.JSON(http.StatusInternalServerError, gin.H{
+			"error": "failed to generate token",
+		})
+		return
+	}
+
+	tokens, err := issueTokenPair(c, &user, login)
+	if err != nil {
+		log.Printf("ERROR: Failed to issue tokens: %v", err)
+		c.JSON(http.StatusInternalServerError, gin.H{
+			"error": "failed to generate token",
+		})
+		return
+	}
+
+	log.Printf("Login code exchanged for tokens (user_id=%d)", user.ID)
+
+	tokens["success"] = true
+	tokens["user"] = user
+	c.JSON(http.StatusOK, tokens)
+}
+
+// HandleRefreshToken rotates a refresh token and issues a new access token.
+// The refresh token is read from its cookie, or from the body for cross-domain clients.
+func HandleRefreshToken(c *gin.Context) {
+	var req struct {
+		RefreshToken string `json:"refresh_token"`
+	}
+	_ = c.ShouldBindJSON(&req)
+
+	refreshToken := req.RefreshToken
+	if refreshToken == "" {
+		refreshToken, _ = c.Cookie(refreshTokenCookie)
+	}
+	if refreshToken == "" {
+		c.JSON(http.StatusUnauthorized, gin.H{
+			"error": "refresh token is required",
+		})
+		return
+	}
+
+	// Rotate the refresh token, then make sure its login hasn't been revoked
+	var login *models.Login
+	userID, familyID, newRefreshToken, err := auth.RotateRefreshToken(refreshToken)
+	if err == nil {
+		login, err = auth.TouchLogin(familyID, c.ClientIP())
+	}
+	if err != nil {
+		log.Printf("Refresh token rejected: %v", err)
+		setAuthCookie(c, accessTokenCookie, "", -1, "/")
+		setAuthCookie(c, refreshTokenCookie, "", -1, refreshCookiePath)
+		c.JSON(http.StatusUnauthorized, gin.H{
+			"error": "invalid or expired refresh token",
+		})
+		return
+	}
+
+	var user models.User
+	if err := database.DB.First(&user, userID).Error; err != nil {
+		c.JSON(http.StatusUnauthorized, gin.H{
+			"error": "user not found",
+		})
+		return
+	}
+
+	accessToken, err := auth.GenerateToken(user.ID, user.Email, login.ID)
+	if err != nil {
+		log.Printf("ERROR: Failed to generate token: %v", err)
+		c.JSON(http.StatusInternalServerError, gin.H{
+			"error": "failed to generate token",
+		})
+		return
+	}
+
+	setAuthCookie(c, accessTokenCookie, accessToken, int(auth.AccessTokenTTL.Seconds()), "/")
+	setAuthCookie(c, refreshTokenCookie, newRefreshToken, int(auth.RefreshTokenTTL.Seconds()), refreshCookiePath)
+
+	c.JSON(http.StatusOK, gin.H{
+		"token":         accessToken,
+		"refresh_token": newRefreshToken,
+		"expires_in":    int(auth.AccessTokenTTL.Seconds()),
+	})
+}
+
+// issueTokenPair creates an access token and a refresh token for a login,
+// sets both cookies and returns them for the response body
+func issueTokenPair(c *gin.Context, user *models.User, login *models.Login) (gin.H, error) {
+	accessToken, err := auth.GenerateToken(user.ID, user.Email, login.ID)
+	if err != nil {
+		return nil, err
+	}
+
+	refreshToken, err := auth.IssueRefreshToken(user.ID, login.FamilyID)
+	if err != nil {
+		return nil, err
+	}
+
+	setAuthCookie(c, accessTokenCookie, accessToken, int(auth.AccessTokenTTL.Seconds()), "/")
+	setAuthCookie(c, refreshTokenCookie, refreshToken, int(auth.RefreshTokenTTL.Seconds()), refreshCookiePath)
+
+	return gin.H{
+		"token":         accessToken,
+		"refresh_token": refreshToken,
+		"expires_in":    int(auth.AccessTokenTTL.Seconds()),
+	}, nil
+}
+
+// setAuthCookie sets an HTTP-only auth cookie; a negative maxAge clears it.
+// Note: SameSite=None requires Secure=true, so production always sets both.
+func setAuthCookie(c *gin.Context, name, value string, maxAge int, path string) {
 	frontendURL := os.Getenv("FRONTEND_URL")
 	isProduction := frontendURL != "" && frontendURL != "http://localhost:5173"
 
 	if isProduction {
+		// Production: Secure=true, SameSite=None for cross-origin
 		c.SetSameSite(http.SameSiteNoneMode)
-		c.SetCookie(
-			"auth_token",
-			req.Token,
-			int(24*time.Hour.Seconds()),
-			"/",
-			"",
-			true, // secure
-			true, // httpOnly
-		)
 	} else {
+		// Development: Secure=false, SameSite=Lax
 		c.SetSameSite(http.SameSiteLaxMode)
-		c.SetCookie(
-			"auth_token",
-			req.Token,
-			int(24*time.Hour.Seconds()),
-			"/",
-			"",
-			false, // secure
-			true,  // httpOnly
-		)
+	}
+	c.SetCookie(name, value, maxAge, path, "", isProduction, true)
+}
+
+// HandleLogout handles user logout. It is authenticated by the refresh token
+// (body or cookie) so a client whose access token has expired can still end
+// its login, or else by a valid access token.
+func HandleLogout(c *gin.Context) {
+	var req struct {
+		RefreshToken string `json:"refresh_token"`
+	}
+	_ = c.ShouldBindJSON(&req)
+
+	refreshToken := req.RefreshToken
+	if refreshToken == "" {
+		refreshToken, _ = c.Cookie(refreshTokenCookie)
+	}
+
+	// Clear cookies whatever happens below
+	setAuthCookie(c, accessTokenCookie, "", -1, "/")
+	setAuthCookie(c, refreshTokenCookie, "", -1, refreshCookiePath)
+
+	authenticated := false
+
+	// Revoke the refresh token family, and the login with it, so this login
+	// can't be renewed
+	if refreshToken != "" {
+		err := auth.RevokeRefreshToken(refreshToken)
+		switch {
+		case err == nil:
+			authenticated = true
+		case errors.Is(err, auth.ErrInvalidRefreshToken):
+		default:
+			log.Printf("Failed to revoke refresh token on logout: %v", err)
+			c.JSON(http.StatusInternalServerError, gin.H{"error": "failed to log out"})
+			return
+		}
+	}
+
+	// Revoke the access token too while it is still valid
+	if claims, err := auth.ValidateToken(accessTokenFromRequest(c)); err == nil {
+		authenticated = true
+		if err := auth.RevokeLogin(claims.UserID, claims.LoginID); err != nil {
+			log.Printf("Failed to revoke login %d on logout: %v", claims.LoginID, err)
+		}
+		if err := auth.RevokeAccessToken(claims); err != nil {
+			log.Printf("Failed to revoke access token on logout: %v", err)
+		}
 	}
 
-	log.Printf("Token exchanged for cookie (user_id=%d)", user.ID)
+	if !authenticated {
+		c.JSON(http.StatusUnauthorized, gin.H{"error": "authentication required"})
+		return
+	}
 
 	c.JSON(http.StatusOK, gin.H{
-		"success": true,
-		"user":    user,
+		"message": "logged out successfully",
 	})
 }
 
-// HandleLogout handles user logout
-func HandleLogout(c *gin.Context) {
-	// Get token from cookie
-	tokenString, err := c.Cookie("auth_token")
+// accessTokenFromRequest returns the Bearer token, or the access token cookie
+// when there's no Authorization header
+func accessTokenFromRequest(c *gin.Context) string {
+	if header := c.GetHeader("Authorization"); header != "" {
+		token, _ := strings.CutPrefix(header, "Bearer ")
+		return token
+	}
+	token, _ := c.Cookie(accessTokenCookie)
+	return token
+}
+
+// HandleListLogins lists the user's active logins (devices)
+func HandleListLogins(c *gin.Context) {
+	userID := c.GetUint("user_id")
+	currentLoginID := c.GetUint("login_id")
+
+	logins, err := auth.ListActiveLogins(userID)
 	if err != nil {
-		c.JSON(http.StatusOK, gin.H{
-			"message": "already logged out",
+		log.Printf("ERROR: Failed to list logins: %v", err)
+		c.JSON(http.StatusInternalServerError, gin.H{
+			"error": "failed to list sessions",
 		})
 		return
 	}
 
-	// Validate token to get user ID
-	claims, err := auth.ValidateToken(tokenString)
-	if err == nil {
-		// Delete session from database
-		database.DB.Where("user_id = ?", claims.UserID).Delete(&models.Session{})
+	sessions := make([]gin.H, len(logins))
+	for i, login := range logins {
+		sessions[i] = gin.H{
+			"id":           login.ID,
+			"user_agent":   login.UserAgent,
+			"ip_address":   login.IPAddress,
+			"issued_at":    login.CreatedAt,
+			"last_seen_at": login.LastSeenAt,
+			"expires_at":   login.ExpiresAt,
+			"current":      login.ID == currentLoginID,
+		}
 	}
 
-	// Clear cookie
-	frontendURL := os.Getenv("FRONTEND_URL")
-	isProduction := frontendURL != "" && frontendURL != "http://localhost:5173"
+	c.JSON(http.StatusOK, gin.H{
+		"sessions": sessions,
+	})
+}
 
-	if isProduction {
-		c.SetSameSite(http.SameSiteNoneMode)
-		c.SetCookie(
-			"auth_token",
-			"",
-			-1,
-			"/",
-			"",
-			true, // secure: must be true for SameSite=None
-			true, // httpOnly
-		)
-	} else {
-		c.SetSameSite(http.SameSiteLaxMode)
-		c.SetCookie(
-			"auth_token",
-			"",
-			-1,
-			"/",
-			"",
-			false, // secure
-			true,  // httpOnly
-		)
+// HandleRevokeLogin revokes a single login; its tokens stop working immediately
+func HandleRevokeLogin(c *gin.Context) {
+	userID := c.GetUint("user_id")
+
+	loginID, err := strconv.ParseUint(c.Param("id"), 10, 64)
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{
+			"error": "invalid session ID",
+		})
+		return
+	}
+
+	if err := auth.RevokeLogin(userID, uint(loginID)); err != nil {
+		if errors.Is(err, gorm.ErrRecordNotFound) {
+			c.JSON(http.StatusNotFound, gin.H{
+				"error": "session not found",
+			})
+			return
+		}
+		log.Printf("ERROR: Failed to revoke login %d: %v", loginID, err)
+		c.JSON(http.StatusInternalServerError, gin.H{
+			"error": "failed to revoke session",
+		})
+		return
+	}
+
+	if uint(loginID) == c.GetUint("login_id") {
+		setAuthCookie(c, accessTokenCookie, "", -1, "/")
+		setAuthCookie(c, refreshTokenCookie, "", -1, refreshCookiePath)
 	}
 
 	c.JSON(http.StatusOK, gin.H{
-		"message": "logged out successfully",
+		"message": "session revoked",
+	})
+}
+
+// HandleRevokeAllLogins revokes all of the user's logins.
+// With ?keep_current=true the login making the request stays signed in.
+func HandleRevokeAllLogins(c *gin.Context) {
+	userID := c.GetUint("user_id")
+
+	keepLoginID := uint(0)
+	if c.Query("keep_current") == "true" {
+		keepLoginID = c.GetUint("login_id")
+	}
+
+	revoked, err := auth.RevokeAllLogins(userID, keepLoginID)
+	if err != nil {
+		log.Printf("ERROR: Failed to revoke logins: %v", err)
+		c.JSON(http.StatusInternalServerError, gin.H{
+			"error": "failed to revoke sessions",
+		})
+		return
+	}
+
+	if keepLoginID == 0 {
+		setAuthCookie(c, accessTokenCookie, "", -1, "/")
+		setAuthCookie(c, refreshTokenCookie, "", -1, refreshCookiePath)
+	}
+
+	c.JSON(http.StatusOK, gin.H{
+		"message": "sessions revoked",
+		"revoked": revoked,
 	})
 }
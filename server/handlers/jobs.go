@@ -0,0 +1,280 @@
+package handlers
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"log"
+	"net/http"
+	"re2no/database"
+	"re2no/jobs"
+	"re2no/models"
+	"re2no/notion"
+	"re2no/reddit"
+	"strconv"
+	"time"
+
+	"github.com/gin-gonic/gin"
+)
+
+// JobKindBulkSave saves a list of posts to one Notion database
+const JobKindBulkSave = "bulk_save"
+
+// maxBulkSaveItems caps how many posts one bulk save can queue
+const maxBulkSaveItems = 100
+
+// RegisterJobProcessors tells the job runner how to process each kind of job
+func RegisterJobProcessors() {
+	jobs.Register(JobKindBulkSave, processBulkSaveItem)
+}
+
+// BulkSaveRequest queues several posts to be saved to one database
+type BulkSaveRequest struct {
+	DatabaseID      string                   `json:"database_id" binding:"required"`
+	IncludeComments int                      `json:"include_comments"` // Default for posts that don't set their own
+	Posts           []notion.SavePostRequest `json:"posts" binding:"required"`
+}
+
+// HandleBulkSave queues posts to be saved to Notion in the background and
+// returns the job to poll for progress
+func HandleBulkSave(c *gin.Context) {
+	userID := c.GetUint("user_id")
+
+	var req BulkSaveRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "database_id and posts are required", "details": err.Error()})
+		return
+	}
+	if len(req.Posts) == 0 || len(req.Posts) > maxBulkSaveItems {
+		c.JSON(http.StatusBadRequest, gin.H{"error": fmt.Sprintf("posts must contain between 1 and %d posts", maxBulkSaveItems)})
+		return
+	}
+
+	connection, ok := getNotionConnection(c, userID)
+	if !ok {
+		return
+	}
+
+	items, err := bulkSaveItems(req.Posts, req.DatabaseID, req.IncludeComments)
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+
+	job, err := enqueueBulkSave(userID, connection.WorkspaceID, req.DatabaseID, items)
+	if err != nil {
+		log.Printf("[Jobs Handler] Failed to queue bulk save: %v", err)
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to queue posts"})
+		return
+	}
+
+	log.Printf("[Jobs Handler] User %d queued job %d to save %d posts to %s", userID, job.ID, len(items), req.DatabaseID)
+
+	c.JSON(http.StatusAccepted, gin.H{
+		"job_id": job.ID,
+		"status": job.Status,
+		"total":  job.Total,
+	})
+}
+
+// bulkSavePayload is what a bulk save item stores. Post carries the listing
+// data when the caller already has it, so the job doesn't fetch the post from
+// Reddit again.
+type bulkSavePayload struct {
+	notion.SavePostRequest
+	Post *reddit.RedditPost `json:"post,omitempty"`
+}
+
+// bulkSaveItems turns posts into job items for a bulk save to databaseID;
+// includeComments applies to posts that don't set their own
+func bulkSaveItems(posts []notion.SavePostRequest, databaseID string, includeComments int) ([]models.JobItem, error) {
+	items := make([]models.JobItem, 0, len(posts))
+	for i, post := range posts {
+		if post.RedditID == "" || post.Title == "" || post.URL == "" {
+			return nil, fmt.Errorf("posts[%d] needs reddit_id, title and url", i)
+		}
+		post.DatabaseID = databaseID
+		if post.IncludeComments == 0 {
+			post.IncludeComments = includeComments
+		}
+
+		payload, err := json.Marshal(bulkSavePayload{SavePostRequest: post, Post: post.Post})
+		if err != nil {
+			return nil, fmt.Errorf("posts[%d] is invalid: %w", i, err)
+		}
+		items = append(items, models.JobItem{Key: post.RedditID, Payload: string(payload)})
+	}
+	return items, nil
+}
+
+// enqueueBulkSave starts a job saving items to a database in a workspace
+func enqueueBulkSave(userID uint, workspaceID, databaseID string, items []models.JobItem) (*models.Job, error) {
+	job := models.Job{
+		UserID:      userID,
+		Kind:        JobKindBulkSave,
+		WorkspaceID: workspaceID,
+		DatabaseID:  databaseID,
+	}
+	if err := jobs.Enqueue(&job, items); err != nil {
+		return nil, err
+	}
+	return &job, nil
+}
+
+// HandleGetJob returns one of the user's jobs with the status of each item
+func HandleGetJob(c *gin.Context) {
+	userID := c.GetUint("user_id")
+
+	jobID, err := strconv.ParseUint(c.Param("id"), 10, 64)
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "Invalid job ID"})
+		return
+	}
+
+	job, err := jobs.Get(userID, uint(jobID))
+	if errors.Is(err, jobs.ErrNotFound) {
+		c.JSON(http.StatusNotFound, gin.H{"error": "Job not found"})
+		return
+	}
+	if err != nil {
+		log.Printf("[Jobs Handler] Failed to load job %d: %v", jobID, err)
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to load job"})
+		return
+	}
+
+	c.JSON(http.StatusOK, job)
+}
+
+// jobEventsKeepAlive is how often an idle event stream gets a comment, so
+// proxies don't close it; events stored by other server instances are picked
+// up at the same interval
+const jobEventsKeepAlive = 15 * time.Second
+
+// HandleJobEvents streams a job's progress as Server-Sent Events until it
+// finishes. Clients resume after a dropped connection by sending the last
+// event ID they received in Last-Event-ID (or ?last_event_id=).
+func HandleJobEvents(c *gin.Context) {
+	userID := c.GetUint("user_id")
+
+	jobID, err := strconv.ParseUint(c.Param("id"), 10, 64)
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "Invalid job ID"})
+		return
+	}
+
+	lastEventID := c.GetHeader("Last-Event-ID")
+	if lastEventID == "" {
+		lastEventID = c.Query("last_event_id")
+	}
+	var after uint64
+	if lastEventID != "" {
+		if after, err = strconv.ParseUint(lastEventID, 10, 64); err != nil {
+			c.JSON(http.StatusBadRequest, gin.H{"error": "Invalid Last-Event-ID"})
+			return
+		}
+	}
+
+	job, err := jobs.Find(userID, uint(jobID))
+	if errors.Is(err, jobs.ErrNotFound) {
+		c.JSON(http.StatusNotFound, gin.H{"error": "Job not found"})
+		return
+	}
+	if err != nil {
+		log.Printf("[Jobs Handler] Failed to load job %d: %v", jobID, err)
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to load job"})
+		return
+	}
+
+	// Subscribe before reading stored events so none fall in between
+	signal, unsubscribe := jobs.Subscribe(job.ID)
+	defer unsubscribe()
+
+	c.Header("Content-Type", "text/event-stream")
+	c.Header("Cache-Control", "no-cache")
+	c.Header("Connection", "keep-alive")
+	c.Header("X-Accel-Buffering", "no") // Stop nginx from buffering the stream
+	c.Status(http.StatusOK)
+
+	keepAlive := time.NewTicker(jobEventsKeepAlive)
+	defer keepAlive.Stop()
+
+	// Finished events are stored together with the final status, so a job
+	// that was already done has one unless the client has seen it
+	done := jobs.IsDone(job.Status)
+
+	cursor := uint(after)
+	for {
+		events, err := jobs.EventsSince(job.ID, cursor)
+		if err != nil {
+			log.Printf("[Jobs Handler] Failed to read events of job %d: %v", job.ID, err)
+			return
+		}
+		for _, event := range events {
+			fmt.Fprintf(c.Writer, "id: %d\nevent: %s\ndata: %s\n\n", event.ID, event.Type, event.Data)
+			cursor = event.ID
+			if event.Type == jobs.EventFinished {
+				c.Writer.Flush()
+				return
+			}
+		}
+		if done {
+			data, _ := json.Marshal(jobs.Progress{
+				Status:    job.Status,
+				Total:     job.Total,
+				Succeeded: job.Succeeded,
+				Skipped:   job.Skipped,
+				Failed:    job.Failed,
+			})
+			fmt.Fprintf(c.Writer, "event: %s\ndata: %s\n\n", jobs.EventFinished, data)
+			c.Writer.Flush()
+			return
+		}
+		c.Writer.Flush()
+
+		select {
+		case <-c.Request.Context().Done():
+			return
+		case <-signal:
+		case <-keepAlive.C:
+			fmt.Fprint(c.Writer, ": keep-alive\n\n")
+		}
+	}
+}
+
+// processBulkSaveItem saves one queued post with the job's workspace
+func processBulkSaveItem(ctx context.Context, job *models.Job, item *models.JobItem) (jobs.Result, error) {
+	var payload bulkSavePayload
+	if err := json.Unmarshal([]byte(item.Payload), &payload); err != nil {
+		return jobs.Result{}, fmt.Errorf("invalid queued post: %w", err)
+	}
+	req := payload.SavePostRequest
+	req.Post = payload.Post
+
+	// Look the connection up per item: the workspace may have been
+	// reauthorized or disconnected since the job was queued
+	var connection models.NotionConnection
+	if err := database.DB.Where("user_id = ? AND workspace_id = ?", job.UserID, job.WorkspaceID).First(&connection).Error; err != nil {
+		return jobs.Result{}, fmt.Errorf("workspace is no longer connected")
+	}
+
+	// Skip before enriching, so an already saved post costs no Reddit requests
+	existing, err := findSavedPost(job.UserID, req.RedditID, req.DatabaseID)
+	if err != nil {
+		return jobs.Result{}, err
+	}
+	if existing != nil {
+		return jobs.Result{NotionPageID: existing.NotionPageID, NotionPageURL: existing.NotionPageURL, Skipped: true}, nil
+	}
+
+	enrichSaveRequest(ctx, &req)
+
+	post, err := savePostToNotion(ctx, notion.NewNotionClient(connection.AccessToken), job.UserID, connection.WorkspaceID, req)
+	if errors.Is(err, errAlreadySaved) {
+		return jobs.Result{NotionPageID: post.NotionPageID, NotionPageURL: post.NotionPageURL, Skipped: true}, nil
+	}
+	if err != nil {
+		return jobs.Result{}, err
+	}
+	return jobs.Result{NotionPageID: post.NotionPageID, NotionPageURL: post.NotionPageURL}, nil
+}
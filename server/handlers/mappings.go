@@ -0,0 +1,197 @@
+package handlers
+
+import (
+	"errors"
+	"log"
+	"net/http"
+	"re2no/database"
+	"re2no/models"
+	"re2no/notion"
+	"sort"
+
+	"github.com/gin-gonic/gin"
+	"github.com/jomei/notionapi"
+	"gorm.io/gorm/clause"
+)
+
+// databaseProperty describes a database column for the mapping editor
+type databaseProperty struct {
+	Name string `json:"name"`
+	Type string `json:"type"`
+}
+
+// HandleGetDatabaseMapping returns the property mapping used when saving to a
+// database: the stored one, or a suggestion when none has been saved yet. It
+// includes the live schema, the fields a property can be mapped to, and any
+// problems the stored mapping has with the current schema.
+func HandleGetDatabaseMapping(c *gin.Context) {
+	userID := c.GetUint("user_id")
+	databaseID := c.Param("id")
+
+	connection, ok := getNotionConnection(c, userID)
+	if !ok {
+		return
+	}
+
+	notionClient := notion.NewNotionClient(connection.AccessToken)
+	mapping := loadDatabaseMapping(userID, databaseID)
+	schema, problems, ok := checkDatabaseMapping(c, notionClient, databaseID, mapping)
+	if !ok {
+		return
+	}
+
+	suggested := mapping == nil
+	if suggested {
+		mapping = notion.SuggestMapping(schema)
+	}
+
+	c.JSON(http.StatusOK, gin.H{
+		"database_id": databaseID,
+		"mapping":     mapping,
+		"suggested":   suggested, // True until a mapping is saved
+		"problems":    problems,
+		"properties":  describeProperties(schema),
+		"fields":      notion.MappableFields(),
+	})
+}
+
+// HandleValidateDatabaseSchema checks the mapping used for a database (the
+// stored one, or the suggestion) against its schema. The schema comes from the
+// cache unless refresh=true is passed.
+func HandleValidateDatabaseSchema(c *gin.Context) {
+	userID := c.GetUint("user_id")
+	databaseID := c.Param("id")
+
+	connection, ok := getNotionConnection(c, userID)
+	if !ok {
+		return
+	}
+
+	notionClient := notion.NewNotionClient(connection.AccessToken)
+	if c.Query("refresh") == "true" {
+		notionClient.InvalidateDatabaseSchema(databaseID)
+	}
+
+	mapping := loadDatabaseMapping(userID, databaseID)
+	schema, problems, ok := checkDatabaseMapping(c, notionClient, databaseID, mapping)
+	if !ok {
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{
+		"database_id": databaseID,
+		"valid":       len(problems) == 0,
+		"suggested":   mapping == nil,
+		"problems":    problems,
+		"properties":  describeProperties(schema),
+	})
+}
+
+// HandlePutDatabaseMapping validates a mapping against the database's live
+// schema and stores it for future saves
+func HandlePutDatabaseMapping(c *gin.Context) {
+	userID := c.GetUint("user_id")
+	databaseID := c.Param("id")
+
+	var req struct {
+		Mapping notion.PropertyMapping `json:"mapping" binding:"required"`
+	}
+	if err := c.ShouldBindJSON(&req); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "mapping is required"})
+		return
+	}
+
+	connection, ok := getNotionConnection(c, userID)
+	if !ok {
+		return
+	}
+
+	_, err := notion.NewNotionClient(connection.AccessToken).ValidateDatabaseMapping(c.Request.Context(), databaseID, req.Mapping)
+	if err != nil {
+		if !respondInvalidMapping(c, err) {
+			log.Printf("[Mapping Handler] Failed to fetch schema of %s: %v", databaseID, err)
+			c.JSON(http.StatusBadGateway, gin.H{"error": "Failed to fetch database from Notion", "details": err.Error()})
+		}
+		return
+	}
+
+	mapping := models.DatabaseMapping{
+		UserID:     userID,
+		DatabaseID: databaseID,
+		Properties: req.Mapping,
+	}
+	err = database.DB.Clauses(clause.OnConflict{
+		Columns:   []clause.Column{{Name: "user_id"}, {Name: "database_id"}},
+		DoUpdates: clause.AssignmentColumns([]string{"properties", "updated_at"}),
+	}).Create(&mapping).Error
+	if err != nil {
+		log.Printf("[Mapping Handler] Failed to save mapping: %v", err)
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to save mapping"})
+		return
+	}
+
+	log.Printf("[Mapping Handler] User %d saved a mapping of %d properties for database %s", userID, len(req.Mapping), databaseID)
+
+	c.JSON(http.StatusOK, gin.H{
+		"success":     true,
+		"database_id": databaseID,
+		"mapping":     req.Mapping,
+	})
+}
+
+// checkDatabaseMapping fetches a database's schema (from the cache when fresh)
+// and lists the problems mapping has with it; a nil mapping has none. It
+// writes an error response and returns false if the schema can't be fetched.
+func checkDatabaseMapping(c *gin.Context, notionClient *notion.NotionClient, databaseID string, mapping notion.PropertyMapping) (notionapi.PropertyConfigs, []string, bool) {
+	var schema notionapi.PropertyConfigs
+	var err error
+	if mapping == nil {
+		schema, err = notionClient.GetDatabaseSchema(c.Request.Context(), databaseID)
+	} else {
+		schema, err = notionClient.ValidateDatabaseMapping(c.Request.Context(), databaseID, mapping)
+	}
+
+	problems := []string{}
+	var mappingErr *notion.MappingError
+	if errors.As(err, &mappingErr) {
+		problems = mappingErr.Problems
+	} else if err != nil {
+		log.Printf("[Mapping Handler] Failed to fetch schema of %s: %v", databaseID, err)
+		c.JSON(http.StatusBadGateway, gin.H{"error": "Failed to fetch database from Notion", "details": err.Error()})
+		return nil, nil, false
+	}
+	return schema, problems, true
+}
+
+// describeProperties lists a schema's properties by name
+func describeProperties(schema notionapi.PropertyConfigs) []databaseProperty {
+	properties := make([]databaseProperty, 0, len(schema))
+	for name, config := range schema {
+		properties = append(properties, databaseProperty{Name: name, Type: string(config.GetType())})
+	}
+	sort.Slice(properties, func(i, j int) bool { return properties[i].Name < properties[j].Name })
+	return properties
+}
+
+// loadDatabaseMapping returns the user's stored mapping for a database, or nil
+func loadDatabaseMapping(userID uint, databaseID string) notion.PropertyMapping {
+	var mapping models.DatabaseMapping
+	if err := database.DB.Where("user_id = ? AND database_id = ?", userID, databaseID).First(&mapping).Error; err != nil {
+		return nil
+	}
+	return mapping.Properties
+}
+
+// respondInvalidMapping writes a 422 listing why a mapping doesn't fit the
+// database. It returns false if err isn't a mapping error.
+func respondInvalidMapping(c *gin.Context, err error) bool {
+	var mappingErr *notion.MappingError
+	if !errors.As(err, &mappingErr) {
+		return false
+	}
+	c.JSON(http.StatusUnprocessableEntity, gin.H{
+		"error":    "The property mapping doesn't match the Notion database",
+		"problems": mappingErr.Problems,
+	})
+	return true
+}
@@ -1,6 +1,7 @@
 package handlers
 
 import (
+	"errors"
 	"log"
 	"net/http"
 	"re2no/database"
@@ -41,63 +42,67 @@ func HandleSaveToNotion(c *gin.Context) {
 
 	log.Printf("[Notion Handler] Saving post: %s to database: %s", req.Title, req.DatabaseID)
 
-	// Get user's latest session for access token
-	var session models.Session
-	if err := database.DB.Where("user_id = ?", user.ID).Order("expires_at DESC").First(&session).Error; err != nil {
-		log.Printf("[Notion Handler] Failed to get user session: %v", err)
-		c.JSON(http.StatusUnauthorized, gin.H{"error": "No valid session found. Please login again."})
+	// A post can be saved once per destination database
+	existing, err := findSavedPost(user.ID, req.RedditID, req.DatabaseID)
+	if err != nil {
+		log.Printf("[Notion Handler] %v", err)
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to check whether the post is already saved"})
+		return
+	}
+	if existing != nil {
+		log.Printf("[Notion Handler] Post %s already saved to database %s", req.RedditID, req.DatabaseID)
+		respondAlreadySaved(c, existing)
 		return
 	}
 
-	// Check if session is expired
-	// if time.Now().After(session.ExpiresAt) {
-	// 	log.Println("[Notion Handler] Session expired")
-	// 	c.JSON(http.StatusUnauthorized, gin.H{"error": "Session expired. Please login again."})
-	// 	return
-	// }
+	// Get the selected workspace's access token before spending Reddit
+	// requests on a save that can't happen
+	connection, ok := getNotionConnection(c, user.ID)
+	if !ok {
+		return
+	}
+
+	// Add the post's media and top comments to the page
+	enrichSaveRequest(c.Request.Context(), &req)
 
-	// Create Notion client with user's access token
-	notionClient := notion.NewNotionClient(session.AccessToken)
+	// Create Notion client with the workspace's access token
+	notionClient := notion.NewNotionClient(connection.AccessToken)
 
-	// Save post to Notion
-	response, err := notionClient.SaveRedditPost(req)
+	// Save post to Notion and record it
+	redditPost, err := savePostToNotion(c.Request.Context(), notionClient, user.ID, connection.WorkspaceID, req)
+	if errors.Is(err, errAlreadySaved) {
+		respondAlreadySaved(c, redditPost)
+		return
+	}
 	if err != nil {
-		log.Printf("[Notion Handler] Failed to save post to Notion: %v", err)
+		log.Printf("[Notion Handler] Failed to save post: %v", err)
+		if respondInvalidMapping(c, err) {
+			return
+		}
 		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to save post to Notion", "details": err.Error()})
 		return
 	}
 
-	log.Printf("[Notion Handler] Successfully saved post. Page ID: %s", response.NotionPageID)
-
-	// Save to database with all post data including Notion URL
-	redditPost := models.RedditPost{
-		UserID:        user.ID,
-		RedditID:      req.RedditID,
-		Subreddit:     req.Subreddit,
-		Title:         req.Title,
-		Content:       req.Content,
-		Author:        req.Author,
-		Score:         req.Score,
-		URL:           req.URL,
-		NotionPageID:  response.NotionPageID,
-		NotionPageURL: response.NotionPageURL,
-	}
-
-	if err := database.DB.Create(&redditPost).Error; err != nil {
-		log.Printf("[Notion Handler] Failed to save post to database: %v", err)
-		// Don't fail the request if DB save fails - post is already in Notion
-	} else {
-		log.Printf("[Notion Handler] Successfully saved to database with URL: %s", response.NotionPageURL)
-	}
+	log.Printf("[Notion Handler] Successfully saved post. Page URL: %s", redditPost.NotionPageURL)
 
 	c.JSON(http.StatusOK, gin.H{
 		"success":         true,
-		"notion_page_id":  response.NotionPageID,
-		"notion_page_url": response.NotionPageURL,
+		"notion_page_id":  redditPost.NotionPageID,
+		"notion_page_url": redditPost.NotionPageURL,
 		"message":         "Post saved to Notion successfully",
 	})
 }
 
+// respondAlreadySaved reports a duplicate save along with the page that already exists
+func respondAlreadySaved(c *gin.Context, post *models.RedditPost) {
+	c.JSON(http.StatusConflict, gin.H{
+		"error":              "Post already saved to this database",
+		"notion_page_id":     post.NotionPageID,
+		"notion_page_url":    post.NotionPageURL,
+		"notion_database_id": post.NotionDatabaseID,
+	})
+}
+
 // HandleGetDatabases retrieves all databases accessible to the user
 func HandleGetDatabases(c *gin.Context) {
 	log.Println("[Notion Handler] Received get databases request")
@@ -117,19 +122,17 @@ func HandleGetDatabases(c *gin.Context) {
 		return
 	}
 
-	// Get user's latest session
-	var session models.Session
-	if err := database.DB.Where("user_id = ?", user.ID).Order("expires_at DESC").First(&session).Error; err != nil {
-		log.Printf("[Notion Handler] Failed to get user session: %v", err)
-		c.JSON(http.StatusUnauthorized, gin.H{"error": "No valid session found. Please login again."})
+	// Get the selected workspace's access token
+	connection, ok := getNotionConnection(c, user.ID)
+	if !ok {
 		return
 	}
 
 	// Create Notion client
-	notionClient := notion.NewNotionClient(session.AccessToken)
+	notionClient := notion.NewNotionClient(connection.AccessToken)
 
 	// Get databases
-	databases, err := notionClient.GetDatabases()
+	databases, err := notionClient.GetDatabases(c.Request.Context())
 	if err != nil {
 		log.Printf("[Notion Handler] Failed to get databases: %v", err)
 		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to retrieve databases", "details": err.Error()})
@@ -177,9 +180,14 @@ func HandleGetSavedPosts(c *gin.Context) {
 		return
 	}
 
-	// Get saved posts from database
+	// Get saved posts from database, optionally only those saved to one workspace
+	query := database.DB.Where("user_id = ?", user.ID)
+	if workspaceID := c.Query("workspace_id"); workspaceID != "" {
+		query = query.Where("workspace_id = ?", workspaceID)
+	}
+
 	var posts []models.RedditPost
-	if err := database.DB.Where("user_id = ?", user.ID).Order("created_at DESC").Find(&posts).Error; err != nil {
+	if err := query.Order("created_at DESC").Find(&posts).Error; err != nil {
 		log.Printf("[Notion Handler] Failed to get saved posts: %v", err)
 		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to retrieve saved posts"})
 		return
@@ -221,44 +229,55 @@ func HandleDeleteSavedPost(c *gin.Context) {
 
 	log.Printf("[Notion Handler] Deleting post with Reddit ID: %s for user: %s", redditID, user.NotionUserID)
 
-	// Find the post in database
-	var post models.RedditPost
-	if err := database.DB.Where("user_id = ? AND reddit_id = ?", user.ID, redditID).First(&post).Error; err != nil {
-		log.Printf("[Notion Handler] Post not found: %v", err)
-		c.JSON(http.StatusNotFound, gin.H{"error": "Post not found"})
-		return
-	}
-
-	// Get user's session for Notion access token
-	var session models.Session
-	if err := database.DB.Where("user_id = ?", user.ID).Order("expires_at DESC").First(&session).Error; err != nil {
-		log.Printf("[Notion Handler] Failed to get user session: %v", err)
-		// Continue with database deletion even if session not found
-	} else if post.NotionPageID != "" {
-		// Delete from Notion if we have both page ID and session
-		notionClient := notion.NewNotionClient(session.AccessToken)
-		if err := notionClient.DeletePage(post.NotionPageID); err != nil {
-			log.Printf("[Notion Handler] Warning: Failed to delete from Notion (continuing with database deletion): %v", err)
-			// Continue with database deletion even if Notion deletion fails
-		} else {
-			log.Printf("[Notion Handler] Successfully deleted from Notion")
-		}
+	// Find the saved copies; database_id narrows it down to one destination,
+	// otherwise every database the post was saved to is removed
+	query := database.DB.Where("user_id = ? AND reddit_id = ?", user.ID, redditID)
+	if databaseID := c.Query("database_id"); databaseID != "" {
+		query = query.Where("notion_database_id = ?", databaseID)
 	}
 
-	// Delete from database
-	result := database.DB.Delete(&post)
-	if result.Error != nil {
-		log.Printf("[Notion Handler] Failed to delete post from database: %v", result.Error)
+	var posts []models.RedditPost
+	if err := query.Find(&posts).Error; err != nil {
+		log.Printf("[Notion Handler] Failed to look up post: %v", err)
 		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to delete post"})
 		return
 	}
-
-	if result.RowsAffected == 0 {
+	if len(posts) == 0 {
 		log.Printf("[Notion Handler] Post not found or not owned by user")
 		c.JSON(http.StatusNotFound, gin.H{"error": "Post not found"})
 		return
 	}
 
+	for _, post := range posts {
+		// Archive the page with the token of the workspace it was saved to
+		// (posts saved before multi-workspace support fall back to the default one)
+		var connection models.NotionConnection
+		connectionQuery := database.DB.Where("user_id = ?", user.ID)
+		if post.WorkspaceID != "" {
+			connectionQuery = connectionQuery.Where("workspace_id = ?", post.WorkspaceID)
+		}
+		if err := connectionQuery.Order("updated_at DESC").First(&connection).Error; err != nil {
+			log.Printf("[Notion Handler] Failed to get workspace connection: %v", err)
+			// Continue with database deletion even if the workspace is no longer connected
+		} else if post.NotionPageID != "" {
+			// Delete from Notion if we have both page ID and a connection
+			notionClient := notion.NewNotionClient(connection.AccessToken)
+			if err := notionClient.DeletePage(c.Request.Context(), post.NotionPageID); err != nil {
+				log.Printf("[Notion Handler] Warning: Failed to delete from Notion (continuing with database deletion): %v", err)
+				// Continue with database deletion even if Notion deletion fails
+			} else {
+				log.Printf("[Notion Handler] Successfully deleted from Notion")
+			}
+		}
+
+		// Delete from database
+		if err := database.DB.Delete(&post).Error; err != nil {
+			log.Printf("[Notion Handler] Failed to delete post from database: %v", err)
+			c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to delete post"})
+			return
+		}
+	}
+
 	log.Printf("[Notion Handler] Successfully deleted post")
 
 	c.JSON(http.StatusOK, gin.H{
@@ -296,19 +315,17 @@ func HandleCreateRedditDatabase(c *gin.Context) {
 		return
 	}
 
-	// Get user's latest session
-	var session models.Session
-	if err := database.DB.Where("user_id = ?", user.ID).Order("expires_at DESC").First(&session).Error; err != nil {
-		log.Printf("[Notion Handler] Failed to get user session: %v", err)
-		c.JSON(http.StatusUnauthorized, gin.H{"error": "No valid session found. Please login again."})
+	// Get the selected workspace's access token
+	connection, ok := getNotionConnection(c, user.ID)
+	if !ok {
 		return
 	}
 
 	// Create Notion client
-	notionClient := notion.NewNotionClient(session.AccessToken)
+	notionClient := notion.NewNotionClient(connection.AccessToken)
 
 	// Create the database
-	database, err := notionClient.CreateRedditPostsDatabase(req.ParentPageID)
+	database, err := notionClient.CreateRedditPostsDatabase(c.Request.Context(), req.ParentPageID)
 	if err != nil {
 		log.Printf("[Notion Handler] Failed to create database: %v", err)
 		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to create database", "details": err.Error()})
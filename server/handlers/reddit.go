@@ -1,23 +1,35 @@
 package handlers
 
 import (
-"log"
-"net/http"
-"re2no/reddit"
-"strconv"
-"strings"
-
-"github.com/gin-gonic/gin"
+	"context"
+	"errors"
+	"fmt"
+	"log"
+	"math"
+	"net/http"
+	"re2no/reddit"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/gin-gonic/gin"
 )
 
 var redditClient = reddit.NewRedditClient()
 
+// fetchPostsDeadline bounds how long a multi-subreddit fetch may take in total
+const fetchPostsDeadline = 20 * time.Second
+
+// maxFetchLimit caps the posts per subreddit and per merged page of a fetch,
+// which is also the most one Reddit listing page returns
+const maxFetchLimit = 100
+
 // HandleFetchPosts fetches Reddit posts based on query parameters
 func HandleFetchPosts(c *gin.Context) {
 	log.Println("=== Fetching Reddit Posts ===")
 
 	// Get query parameters
-	subreddits := c.Query("subreddits")      // Comma-separated list
+	subreddits := c.Query("subreddits") // Comma-separated list
 	keyword := c.Query("keyword")
 	sortBy := c.Query("sort")
 	dateRange := c.Query("date_range")
@@ -30,6 +42,12 @@ func HandleFetchPosts(c *gin.Context) {
 			limit = parsedLimit
 		}
 	}
+	if limit < 1 {
+		limit = 1
+	}
+	if limit > maxFetchLimit {
+		limit = maxFetchLimit
+	}
 
 	// Default to hot if not specified
 	if sortBy == "" {
@@ -37,7 +55,7 @@ func HandleFetchPosts(c *gin.Context) {
 	}
 
 	log.Printf("Query params - Subreddits: %s, Keyword: %s, Sort: %s, DateRange: %s, Limit: %d",
-subreddits, keyword, sortBy, dateRange, limit)
+		subreddits, keyword, sortBy, dateRange, limit)
 
 	// Split subreddits
 	subredditList := []string{"all"}
@@ -51,41 +69,173 @@ subreddits, keyword, sortBy, dateRange, limit)
 		}
 	}
 
-	// Fetch posts from each subreddit
-	allPosts := []reddit.RedditPost{}
+	filter, err := parsePostFilter(c)
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+
+	params := reddit.MultiFetchParams{
+		Subreddits: subredditList,
+		Keyword:    keyword,
+		Sort:       sortBy,
+		TimeRange:  dateRange,
+		Limit:      limit,
+		Filter:     filter,
+	}
+
+	// A cursor from a previous response continues the same query
+	if cursor := c.Query("cursor"); cursor != "" {
+		feedCursor, err := reddit.DecodeCursor(cursor, params)
+		if err != nil {
+			c.JSON(http.StatusBadRequest, gin.H{"error": "invalid cursor for this query"})
+			return
+		}
+		params.Cursor = feedCursor
+	}
+
+	// Fetch all subreddits in parallel under one deadline; a slow subreddit
+	// is reported as an error instead of holding up the response
+	ctx, cancel := context.WithTimeout(c.Request.Context(), fetchPostsDeadline)
+	defer cancel()
 
-	for _, subreddit := range subredditList {
-		log.Printf("Fetching from r/%s...", subreddit)
+	result := redditClient.FetchMulti(ctx, params)
 
-		var posts []reddit.RedditPost
-		var err error
+	for _, subErr := range result.Errors {
+		log.Printf("ERROR: Failed to fetch from r/%s: %v", subErr.Subreddit, subErr.Err)
+	}
 
-		if keyword != "" {
-			// Search with keyword
-			posts, err = redditClient.SearchPosts(subreddit, keyword, sortBy, limit)
-		} else {
-			// Fetch without keyword
-			posts, err = redditClient.FetchPosts(reddit.FetchPostsParams{
-Subreddit: subreddit,
-Sort:      sortBy,
-TimeRange: dateRange,
-Limit:     limit,
-})
+	// Nothing succeeded: report the failure (as a rate limit if that's what it was)
+	if result.AllFailed {
+		if respondRateLimited(c, result.Errors[0].Err) {
+			return
 		}
+		c.JSON(http.StatusBadGateway, gin.H{
+			"error":  "failed to fetch posts from Reddit",
+			"errors": result.Errors,
+		})
+		return
+	}
+
+	log.Printf("=== Total Posts Fetched: %d ===", len(result.Posts))
+
+	// An empty cursor means every subreddit has been read to the end
+	nextCursor := ""
+	if result.Cursor != nil {
+		nextCursor = result.Cursor.Encode()
+	}
 
+	c.JSON(http.StatusOK, gin.H{
+		"posts":  result.Posts,
+		"count":  len(result.Posts),
+		"errors": result.Errors,
+		"cursor": nextCursor,
+	})
+}
+
+// parsePostFilter reads the optional post filters from the query string:
+// min_score, min_comments, max_age (e.g. "24h"), nsfw and spoilers
+// (include, exclude or only), and comma-separated flair, exclude_flair,
+// domain, exclude_domain and kind (self, link, image, video, gallery)
+func parsePostFilter(c *gin.Context) (*reddit.PostFilter, error) {
+	filter := &reddit.PostFilter{
+		NSFW:           c.Query("nsfw"),
+		Spoilers:       c.Query("spoilers"),
+		Flairs:         splitList(c.Query("flair")),
+		ExcludeFlairs:  splitList(c.Query("exclude_flair")),
+		Domains:        splitList(c.Query("domain")),
+		ExcludeDomains: splitList(c.Query("exclude_domain")),
+		Kinds:          splitList(strings.ToLower(c.Query("kind"))),
+	}
+
+	if value := c.Query("min_score"); value != "" {
+		minScore, err := strconv.Atoi(value)
+		if err != nil {
+			return nil, fmt.Errorf("min_score must be a number")
+		}
+		filter.MinScore = &minScore
+	}
+	if value := c.Query("min_comments"); value != "" {
+		minComments, err := strconv.Atoi(value)
+		if err != nil {
+			return nil, fmt.Errorf("min_comments must be a number")
+		}
+		filter.MinComments = minComments
+	}
+	if value := c.Query("max_age"); value != "" {
+		maxAge, err := time.ParseDuration(value)
 		if err != nil {
-			log.Printf("ERROR: Failed to fetch from r/%s: %v", subreddit, err)
-			continue
+			return nil, fmt.Errorf("max_age must be a duration such as 24h")
+		}
+		filter.MaxAge = maxAge
+	}
+
+	if err := filter.Validate(); err != nil {
+		return nil, err
+	}
+	return filter, nil
+}
+
+// splitList splits a comma-separated query value, dropping empty entries
+func splitList(value string) []string {
+	var values []string
+	for _, v := range strings.Split(value, ",") {
+		if v = strings.TrimSpace(v); v != "" {
+			values = append(values, v)
 		}
+	}
+	return values
+}
+
+// HandleFetchComments fetches the comment thread for a single Reddit post
+func HandleFetchComments(c *gin.Context) {
+	postID := strings.TrimPrefix(c.Param("id"), "t3_")
+	if postID == "" {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "post ID is required"})
+		return
+	}
 
-		log.Printf("Fetched %d posts from r/%s", len(posts), subreddit)
-		allPosts = append(allPosts, posts...)
+	params := reddit.FetchCommentsParams{
+		PostID: postID,
+		Sort:   c.Query("sort"),
 	}
+	if depth, err := strconv.Atoi(c.Query("depth")); err == nil {
+		params.Depth = depth
+	}
+	if limit, err := strconv.Atoi(c.Query("limit")); err == nil {
+		params.Limit = limit
+	}
+
+	log.Printf("Fetching comments for post %s (sort=%s, depth=%d, limit=%d)", postID, params.Sort, params.Depth, params.Limit)
 
-	log.Printf("=== Total Posts Fetched: %d ===", len(allPosts))
+	comments, err := redditClient.FetchComments(c.Request.Context(), params)
+	if err != nil {
+		log.Printf("ERROR: Failed to fetch comments for %s: %v", postID, err)
+		if respondRateLimited(c, err) {
+			return
+		}
+		c.JSON(http.StatusBadGateway, gin.H{"error": "failed to fetch comments", "details": err.Error()})
+		return
+	}
 
 	c.JSON(http.StatusOK, gin.H{
-"posts": allPosts,
-"count": len(allPosts),
-})
+		"comments": comments,
+		"count":    len(comments),
+	})
+}
+
+// respondRateLimited writes a 429 with Retry-After when err is a Reddit rate limit
+func respondRateLimited(c *gin.Context, err error) bool {
+	var rateLimited *reddit.RateLimitedError
+	if !errors.As(err, &rateLimited) {
+		return false
+	}
+
+	retryAfter := int(math.Ceil(rateLimited.RetryAfter.Seconds()))
+	c.Header("Retry-After", strconv.Itoa(retryAfter))
+	c.JSON(http.StatusTooManyRequests, gin.H{
+		"error":       "Reddit rate limit reached, please try again later",
+		"retry_after": retryAfter,
+	})
+	return true
 }
@@ -0,0 +1,348 @@
+package handlers
+
+import (
+	"context"
+	"log"
+	"net/http"
+	"re2no/auth"
+	"re2no/database"
+	"re2no/models"
+	"re2no/notion"
+	"re2no/reddit"
+	"strings"
+
+	"github.com/gin-gonic/gin"
+	"golang.org/x/oauth2"
+)
+
+// maxImportItems caps how many saved items one import request queues;
+// clients continue from the returned "after" cursor
+const maxImportItems = 100
+
+// HandleRedditConnect starts linking the user's Reddit account and returns the consent URL
+func HandleRedditConnect(c *gin.Context) {
+	userID := c.GetUint("user_id")
+
+	state, err := auth.IssueAccountLinkState(auth.ProviderReddit, userID)
+	if err != nil {
+		log.Printf("[Reddit Account Handler] Failed to issue state: %v", err)
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to start Reddit connection"})
+		return
+	}
+
+	authURL, err := reddit.AccountAuthURL(state)
+	if err != nil {
+		log.Printf("[Reddit Account Handler] %v", err)
+		c.JSON(http.StatusServiceUnavailable, gin.H{"error": "Reddit account linking is not configured on this server"})
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{
+		"url": authURL,
+	})
+}
+
+// HandleRedditCallback completes linking with the code and state Reddit sent
+// back to the frontend. The state must have been issued to the calling user.
+func HandleRedditCallback(c *gin.Context) {
+	userID := c.GetUint("user_id")
+
+	var req struct {
+		Code  string `json:"code" binding:"required"`
+		State string `json:"state" binding:"required"`
+	}
+	if err := c.ShouldBindJSON(&req); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "code and state are required"})
+		return
+	}
+
+	if err := auth.ConsumeAccountLinkState(auth.ProviderReddit, req.State, userID); err != nil {
+		log.Printf("[Reddit Account Handler] Invalid state: %v", err)
+		c.JSON(http.StatusBadRequest, gin.H{"error": "invalid state parameter"})
+		return
+	}
+
+	token, err := reddit.ExchangeAccountCode(c.Request.Context(), req.Code)
+	if err != nil {
+		log.Printf("[Reddit Account Handler] Failed to exchange code: %v", err)
+		c.JSON(http.StatusBadGateway, gin.H{"error": "Failed to connect Reddit account"})
+		return
+	}
+
+	source, err := reddit.AccountTokenSource(c.Request.Context(), token)
+	if err != nil {
+		c.JSON(http.StatusServiceUnavailable, gin.H{"error": "Reddit account linking is not configured on this server"})
+		return
+	}
+
+	identity, err := redditClient.FetchIdentity(c.Request.Context(), source)
+	if err != nil {
+		log.Printf("[Reddit Account Handler] Failed to fetch identity: %v", err)
+		c.JSON(http.StatusBadGateway, gin.H{"error": "Failed to connect Reddit account"})
+		return
+	}
+
+	// One linked Reddit account per user; linking again replaces it
+	var connection models.RedditConnection
+	database.DB.Where("user_id = ?", userID).First(&connection)
+
+	connection.UserID = userID
+	connection.RedditUserID = identity.ID
+	connection.Username = identity.Name
+	connection.AccessToken = token.AccessToken
+	connection.RefreshToken = token.RefreshToken
+	connection.TokenType = token.TokenType
+	connection.ExpiresAt = token.Expiry
+	if scope, ok := token.Extra("scope").(string); ok {
+		connection.Scope = scope
+	}
+
+	if err := database.DB.Save(&connection).Error; err != nil {
+		log.Printf("[Reddit Account Handler] Failed to save connection: %v", err)
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to save Reddit connection"})
+		return
+	}
+
+	log.Printf("[Reddit Account Handler] User %d linked Reddit account u/%s", userID, identity.Name)
+
+	c.JSON(http.StatusOK, gin.H{
+		"success":    true,
+		"connection": connection,
+	})
+}
+
+// HandleGetRedditConnection returns the user's linked Reddit account, if any
+func HandleGetRedditConnection(c *gin.Context) {
+	userID := c.GetUint("user_id")
+
+	var connection models.RedditConnection
+	if err := database.DB.Where("user_id = ?", userID).First(&connection).Error; err != nil {
+		c.JSON(http.StatusOK, gin.H{
+			"connected": false,
+		})
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{
+		"connected":  true,
+		"connection": connection,
+	})
+}
+
+// HandleDisconnectReddit unlinks the user's Reddit account and deletes its tokens
+func HandleDisconnectReddit(c *gin.Context) {
+	userID := c.GetUint("user_id")
+
+	result := database.DB.Where("user_id = ?", userID).Delete(&models.RedditConnection{})
+	if result.Error != nil {
+		log.Printf("[Reddit Account Handler] Failed to disconnect: %v", result.Error)
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to disconnect Reddit account"})
+		return
+	}
+	if result.RowsAffected == 0 {
+		c.JSON(http.StatusNotFound, gin.H{"error": "No Reddit account linked"})
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{
+		"success": true,
+		"message": "Reddit account disconnected",
+	})
+}
+
+// ImportSavedRequest is the body of POST /api/reddit/saved/import
+type ImportSavedRequest struct {
+	DatabaseID string `json:"database_id" binding:"required"`
+	Limit      int    `json:"limit"` // Items to process, at most 100
+	After      string `json:"after"` // Cursor from a previous import to continue from
+}
+
+// HandleImportSaved queues the user's Reddit saved posts and comments to be
+// saved into a Notion database in the background, skipping items already saved
+// there, and returns the job to follow
+func HandleImportSaved(c *gin.Context) {
+	userID := c.GetUint("user_id")
+	ctx := c.Request.Context()
+
+	var req ImportSavedRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "database_id is required"})
+		return
+	}
+	if req.Limit <= 0 || req.Limit > maxImportItems {
+		req.Limit = maxImportItems
+	}
+
+	var redditConnection models.RedditConnection
+	if err := database.DB.Where("user_id = ?", userID).First(&redditConnection).Error; err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "Link your Reddit account first"})
+		return
+	}
+
+	notionConnection, ok := getNotionConnection(c, userID)
+	if !ok {
+		return
+	}
+
+	source, err := redditConnectionTokenSource(ctx, &redditConnection)
+	if err != nil {
+		c.JSON(http.StatusServiceUnavailable, gin.H{"error": "Reddit account linking is not configured on this server"})
+		return
+	}
+	defer persistRedditToken(&redditConnection, source)
+
+	log.Printf("[Reddit Account Handler] Queuing up to %d saved items for u/%s into %s", req.Limit, redditConnection.Username, req.DatabaseID)
+
+	var saveReqs []notion.SavePostRequest
+	after := req.After
+
+	for len(saveReqs) < req.Limit {
+		page, err := redditClient.FetchSaved(ctx, source, reddit.FetchSavedParams{
+			Username: redditConnection.Username,
+			After:    after,
+			Limit:    req.Limit - len(saveReqs),
+		})
+		if err != nil {
+			log.Printf("[Reddit Account Handler] Failed to fetch saved items: %v", err)
+			if respondRateLimited(c, err) {
+				return
+			}
+			c.JSON(http.StatusBadGateway, gin.H{"error": "Failed to fetch saved items from Reddit", "details": err.Error()})
+			return
+		}
+
+		for _, item := range page.Items {
+			saveReqs = append(saveReqs, savedItemToRequest(item, req.DatabaseID))
+		}
+
+		after = page.After
+		if after == "" || len(page.Items) == 0 {
+			break
+		}
+	}
+
+	// Leave out what's already in the database so the job doesn't spend
+	// Reddit requests on it
+	ids := make([]string, len(saveReqs))
+	for i, saveReq := range saveReqs {
+		ids[i] = saveReq.RedditID
+	}
+	alreadySaved, err := savedRedditIDs(userID, req.DatabaseID, ids)
+	if err != nil {
+		log.Printf("[Reddit Account Handler] %v", err)
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to check saved items"})
+		return
+	}
+	unsaved := make([]notion.SavePostRequest, 0, len(saveReqs))
+	for _, saveReq := range saveReqs {
+		if !alreadySaved[saveReq.RedditID] {
+			unsaved = append(unsaved, saveReq)
+		}
+	}
+	skipped := len(saveReqs) - len(unsaved)
+
+	if len(unsaved) == 0 {
+		c.JSON(http.StatusOK, gin.H{
+			"total":   0,
+			"skipped": skipped,
+			"after":   after,
+		})
+		return
+	}
+
+	items, err := bulkSaveItems(unsaved, req.DatabaseID, 0)
+	if err != nil {
+		log.Printf("[Reddit Account Handler] Saved items can't be imported: %v", err)
+		c.JSON(http.StatusBadGateway, gin.H{"error": "Reddit returned an invalid saved item", "details": err.Error()})
+		return
+	}
+
+	job, err := enqueueBulkSave(userID, notionConnection.WorkspaceID, req.DatabaseID, items)
+	if err != nil {
+		log.Printf("[Reddit Account Handler] Failed to queue import: %v", err)
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to queue saved items"})
+		return
+	}
+
+	log.Printf("[Reddit Account Handler] User %d queued job %d to import %d saved items (%d already saved)", userID, job.ID, len(items), skipped)
+
+	c.JSON(http.StatusAccepted, gin.H{
+		"job_id":  job.ID,
+		"status":  job.Status,
+		"total":   job.Total,
+		"skipped": skipped, // Already saved, so not queued
+		"after":   after,   // Empty once the whole saved list has been queued
+	})
+}
+
+// savedItemToRequest maps a saved post or comment to a Notion save request.
+// Comments are stored under their fullname (t1_...) so they can't collide with post IDs.
+func savedItemToRequest(item reddit.SavedItem, databaseID string) notion.SavePostRequest {
+	if item.Comment != nil {
+		comment := item.Comment
+		return notion.SavePostRequest{
+			Title:      "Comment on: " + comment.LinkTitle,
+			Subreddit:  comment.Subreddit,
+			Content:    comment.Body,
+			Author:     comment.Author,
+			Score:      comment.Score,
+			URL:        "https://reddit.com" + comment.Permalink,
+			RedditID:   comment.Name,
+			DatabaseID: databaseID,
+		}
+	}
+
+	return postSaveRequest(item.Post, databaseID)
+}
+
+// isCommentID reports whether a save request's RedditID is a saved comment's
+// fullname rather than a post ID
+func isCommentID(redditID string) bool {
+	return strings.HasPrefix(redditID, "t1_")
+}
+
+// postSaveRequest maps a listing post to a Notion save request
+func postSaveRequest(post *reddit.RedditPost, databaseID string) notion.SavePostRequest {
+	content := post.SelfText
+	if content == "" {
+		content = post.URL
+	}
+	return notion.SavePostRequest{
+		Title:      post.Title,
+		Subreddit:  post.Subreddit,
+		Content:    content,
+		Author:     post.Author,
+		Score:      post.Score,
+		URL:        "https://reddit.com" + post.Permalink,
+		RedditID:   post.ID,
+		DatabaseID: databaseID,
+		Post:       post,
+	}
+}
+
+// redditConnectionTokenSource returns a refreshing token source for a linked account
+func redditConnectionTokenSource(ctx context.Context, connection *models.RedditConnection) (oauth2.TokenSource, error) {
+	return reddit.AccountTokenSource(ctx, &oauth2.Token{
+		AccessToken:  connection.AccessToken,
+		RefreshToken: connection.RefreshToken,
+		TokenType:    connection.TokenType,
+		Expiry:       connection.ExpiresAt,
+	})
+}
+
+// persistRedditToken stores the access token if the source refreshed it
+func persistRedditToken(connection *models.RedditConnection, source oauth2.TokenSource) {
+	token, err := source.Token()
+	if err != nil || token.AccessToken == connection.AccessToken {
+		return
+	}
+
+	connection.AccessToken = token.AccessToken
+	connection.ExpiresAt = token.Expiry
+	if token.RefreshToken != "" {
+		connection.RefreshToken = token.RefreshToken
+	}
+	if err := database.DB.Save(connection).Error; err != nil {
+		log.Printf("[Reddit Account Handler] Failed to store refreshed token: %v", err)
+	}
+}
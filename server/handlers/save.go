@@ -0,0 +1,145 @@
+package handlers
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"log"
+	"re2no/database"
+	"re2no/models"
+	"re2no/notion"
+	"re2no/reddit"
+
+	"gorm.io/gorm"
+)
+
+// errAlreadySaved means the post is already in the destination database
+var errAlreadySaved = errors.New("post already saved to this database")
+
+// savePostToNotion creates the Notion page for a post and records it in
+// reddit_posts. If the post is already saved to req.DatabaseID it returns the
+// existing row along with errAlreadySaved, without touching Notion.
+func savePostToNotion(ctx context.Context, notionClient *notion.NotionClient, userID uint, workspaceID string, req notion.SavePostRequest) (*models.RedditPost, error) {
+	// A post can be saved once per destination database
+	existing, err := findSavedPost(userID, req.RedditID, req.DatabaseID)
+	if err != nil {
+		return nil, err
+	}
+	if existing != nil {
+		return existing, errAlreadySaved
+	}
+
+	req.Mapping = loadDatabaseMapping(userID, req.DatabaseID)
+
+	response, err := notionClient.SaveRedditPost(ctx, req)
+	if err != nil {
+		return nil, fmt.Errorf("failed to save post to Notion: %w", err)
+	}
+
+	redditPost := models.RedditPost{
+		UserID:           userID,
+		RedditID:         req.RedditID,
+		Subreddit:        req.Subreddit,
+		Title:            req.Title,
+		Content:          req.Content,
+		Author:           req.Author,
+		Score:            req.Score,
+		URL:              req.URL,
+		NotionPageID:     response.NotionPageID,
+		NotionPageURL:    response.NotionPageURL,
+		NotionDatabaseID: req.DatabaseID,
+		WorkspaceID:      workspaceID,
+	}
+
+	if err := database.DB.Create(&redditPost).Error; err != nil {
+		// Archive the page we just created so Notion and the database stay in sync,
+		// even if the request that created it has since been canceled
+		if archiveErr := notionClient.DeletePage(context.WithoutCancel(ctx), response.NotionPageID); archiveErr != nil {
+			log.Printf("[Notion Handler] Warning: Failed to archive orphaned page %s: %v", response.NotionPageID, archiveErr)
+		}
+
+		// A concurrent save of the same post won the race
+		if existing, findErr := findSavedPost(userID, req.RedditID, req.DatabaseID); findErr == nil && existing != nil {
+			return existing, errAlreadySaved
+		}
+
+		return nil, fmt.Errorf("failed to record saved post: %w", err)
+	}
+
+	return &redditPost, nil
+}
+
+// findSavedPost looks up a post saved to a specific database, returning nil
+// if it hasn't been. Posts saved before destinations were recorded have no
+// database ID and count as saved to every database.
+func findSavedPost(userID uint, redditID, databaseID string) (*models.RedditPost, error) {
+	var post models.RedditPost
+	err := database.DB.Where("user_id = ? AND reddit_id = ? AND notion_database_id IN ?", userID, redditID, []string{databaseID, ""}).
+		Order("notion_database_id DESC"). // Prefer the exact destination over a legacy row
+		First(&post).Error
+	if errors.Is(err, gorm.ErrRecordNotFound) {
+		return nil, nil
+	}
+	if err != nil {
+		return nil, fmt.Errorf("failed to look up saved post: %w", err)
+	}
+	return &post, nil
+}
+
+// savedRedditIDs returns which of ids the user already saved to databaseID,
+// including legacy saves with no database ID
+func savedRedditIDs(userID uint, databaseID string, ids []string) (map[string]bool, error) {
+	alreadySaved := make(map[string]bool)
+	if len(ids) == 0 {
+		return alreadySaved, nil
+	}
+
+	var saved []string
+	err := database.DB.Model(&models.RedditPost{}).
+		Where("user_id = ? AND notion_database_id IN ? AND reddit_id IN ?", userID, []string{databaseID, ""}, ids).
+		Pluck("reddit_id", &saved).Error
+	if err != nil {
+		return nil, fmt.Errorf("failed to check saved posts: %w", err)
+	}
+	for _, id := range saved {
+		alreadySaved[id] = true
+	}
+	return alreadySaved, nil
+}
+
+// enrichSaveRequest fetches the post's listing data, so its media can be
+// embedded natively, and its top comments when req.IncludeComments is set.
+// Failures are only logged: the post itself is what matters.
+func enrichSaveRequest(ctx context.Context, req *notion.SavePostRequest) {
+	// A saved comment has no media or replies to add, and its ID isn't a post ID
+	if isCommentID(req.RedditID) {
+		return
+	}
+
+	if req.Post == nil {
+		if post, err := redditClient.FetchPost(ctx, req.RedditID); err != nil {
+			// The page just gets a plain link instead of embedded media
+			log.Printf("[Notion Handler] Failed to fetch post media, saving without it: %v", err)
+		} else {
+			req.Post = post
+		}
+	}
+
+	if req.IncludeComments > 0 {
+		comments, err := redditClient.FetchComments(ctx, reddit.FetchCommentsParams{
+			PostID: req.RedditID,
+			Sort:   "top",
+			Depth:  3,
+			Limit:  req.IncludeComments,
+		})
+		if err != nil {
+			log.Printf("[Notion Handler] Failed to fetch comments, saving without them: %v", err)
+			return
+		}
+		if len(comments) > req.IncludeComments {
+			comments = comments[:req.IncludeComments]
+		}
+		req.Comments = comments
+		log.Printf("[Notion Handler] Including %d comments", len(comments))
+	}
+}
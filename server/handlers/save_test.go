@@ -0,0 +1,56 @@
+package handlers
+
+import (
+	"testing"
+
+	"re2no/database"
+	"re2no/models"
+)
+
+func TestFindSavedPost(t *testing.T) {
+	connectTestDB(t)
+	user := createTestUser(t)
+
+	saved := []models.RedditPost{
+		{UserID: user.ID, RedditID: "post", NotionDatabaseID: "database"},
+		{UserID: user.ID, RedditID: "legacy", NotionDatabaseID: ""},
+		{UserID: user.ID, RedditID: "both", NotionDatabaseID: ""},
+		{UserID: user.ID, RedditID: "both", NotionDatabaseID: "database"},
+	}
+	for i := range saved {
+		saved[i].Title, saved[i].Subreddit, saved[i].URL = "title", "golang", "https://reddit.com"
+		saved[i].NotionPageID = "page-" + saved[i].RedditID + "-" + saved[i].NotionDatabaseID
+		if err := database.DB.Create(&saved[i]).Error; err != nil {
+			t.Fatal(err)
+		}
+	}
+
+	tests := []struct {
+		name       string
+		redditID   string
+		databaseID string
+		wantPage   string // "" when the post isn't saved
+	}{
+		{"saved to the database", "post", "database", "page-post-database"},
+		{"saved to another database", "post", "other-database", ""},
+		{"never saved", "missing", "database", ""},
+		{"legacy save matches any database", "legacy", "other-database", "page-legacy-"},
+		{"exact destination wins over legacy", "both", "database", "page-both-database"},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			post, err := findSavedPost(user.ID, tt.redditID, tt.databaseID)
+			if err != nil {
+				t.Fatal(err)
+			}
+			got := ""
+			if post != nil {
+				got = post.NotionPageID
+			}
+			if got != tt.wantPage {
+				t.Errorf("findSavedPost(%q, %q) = %q, want %q", tt.redditID, tt.databaseID, got, tt.wantPage)
+			}
+		})
+	}
+}
@@ -0,0 +1,502 @@
+package handlers
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"log"
+	"net/http"
+	"re2no/database"
+	"re2no/models"
+	"re2no/notion"
+	"re2no/reddit"
+	"re2no/schedule"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/gin-gonic/gin"
+	"gorm.io/gorm"
+)
+
+// Subscription run statuses
+const (
+	RunRunning   = "running"
+	RunCompleted = "completed" // New posts (if any) were handed to a bulk save job
+	RunFailed    = "failed"
+)
+
+// maxSubscriptionRuns is how many past runs the run history returns
+const maxSubscriptionRuns = 50
+
+// SubscriptionRequest creates or replaces a subscription
+type SubscriptionRequest struct {
+	Name       string              `json:"name" binding:"required"`
+	Subreddits []string            `json:"subreddits" binding:"required"`
+	Keyword    string              `json:"keyword"`
+	Sort       string              `json:"sort"`       // hot (default), new, top, rising or controversial
+	TimeRange  string              `json:"time_range"` // For top and controversial: hour, day, week, month, year or all
+	Filter     *SubscriptionFilter `json:"filter"`
+	Limit      int                 `json:"limit"` // Posts fetched per run, 25 by default
+	DatabaseID string              `json:"database_id" binding:"required"`
+	Schedule   string              `json:"schedule" binding:"required"` // Cron expression
+	Timezone   string              `json:"timezone"`                    // IANA name the schedule is read in, UTC by default
+	Enabled    *bool               `json:"enabled"`                     // Defaults to true
+}
+
+// SubscriptionFilter is a reddit.PostFilter as subscriptions take and return
+// it: max_age is a duration such as "24h", like the max_age query parameter
+type SubscriptionFilter struct {
+	reddit.PostFilter
+	MaxAge string `json:"max_age,omitempty"`
+}
+
+// postFilter parses the filter into the form the Reddit client applies
+func (f *SubscriptionFilter) postFilter() (*reddit.PostFilter, error) {
+	filter := f.PostFilter
+	filter.MaxAge = 0
+	if f.MaxAge != "" {
+		maxAge, err := time.ParseDuration(f.MaxAge)
+		if err != nil {
+			return nil, fmt.Errorf("max_age must be a duration such as 24h")
+		}
+		filter.MaxAge = maxAge
+	}
+	if err := filter.Validate(); err != nil {
+		return nil, err
+	}
+	if filter.IsZero() {
+		return nil, nil
+	}
+	return &filter, nil
+}
+
+// subscriptionResponse is a subscription with its filter written the way
+// requests give it
+type subscriptionResponse struct {
+	models.Subscription
+	Filter *SubscriptionFilter `json:"filter"`
+}
+
+func newSubscriptionResponse(sub *models.Subscription) subscriptionResponse {
+	resp := subscriptionResponse{Subscription: *sub}
+	if sub.Filter != nil {
+		resp.Filter = &SubscriptionFilter{PostFilter: *sub.Filter}
+		if sub.Filter.MaxAge > 0 {
+			resp.Filter.MaxAge = sub.Filter.MaxAge.String()
+		}
+	}
+	return resp
+}
+
+// apply validates the request and copies it onto sub
+func (req *SubscriptionRequest) apply(sub *models.Subscription) error {
+	subreddits := make([]string, 0, len(req.Subreddits))
+	for _, name := range req.Subreddits {
+		if name = strings.TrimPrefix(strings.TrimSpace(name), "r/"); name != "" {
+			subreddits = append(subreddits, name)
+		}
+	}
+	if len(subreddits) == 0 {
+		return fmt.Errorf("at least one subreddit is required")
+	}
+
+	if req.Sort == "" {
+		req.Sort = "hot"
+	}
+	switch req.Sort {
+	case "hot", "new", "top", "rising", "controversial":
+	default:
+		return fmt.Errorf("unknown sort %q", req.Sort)
+	}
+	switch req.TimeRange {
+	case "", "hour", "day", "week", "month", "year", "all":
+	default:
+		return fmt.Errorf("unknown time range %q", req.TimeRange)
+	}
+
+	if req.Limit == 0 {
+		req.Limit = 25
+	}
+	if req.Limit < 1 || req.Limit > maxBulkSaveItems {
+		return fmt.Errorf("limit must be between 1 and %d", maxBulkSaveItems)
+	}
+
+	var filter *reddit.PostFilter
+	if req.Filter != nil {
+		var err error
+		if filter, err = req.Filter.postFilter(); err != nil {
+			return err
+		}
+	}
+
+	if _, err := schedule.Parse(req.Schedule); err != nil {
+		return fmt.Errorf("invalid schedule: %w", err)
+	}
+	if req.Timezone == "" {
+		req.Timezone = "UTC"
+	}
+	if _, err := time.LoadLocation(req.Timezone); err != nil {
+		return fmt.Errorf("unknown timezone %q", req.Timezone)
+	}
+
+	sub.Name = req.Name
+	sub.Subreddits = subreddits
+	sub.Keyword = req.Keyword
+	sub.Sort = req.Sort
+	sub.TimeRange = req.TimeRange
+	sub.Filter = filter
+	sub.Limit = req.Limit
+	sub.DatabaseID = req.DatabaseID
+	sub.Schedule = req.Schedule
+	sub.Timezone = req.Timezone
+	sub.Enabled = req.Enabled == nil || *req.Enabled
+	sub.NextRunAt = nextSubscriptionRun(sub, time.Now())
+	return nil
+}
+
+// nextSubscriptionRun returns when an enabled subscription should next run
+// after now, or nil if it's disabled or its schedule never matches
+func nextSubscriptionRun(sub *models.Subscription, now time.Time) *time.Time {
+	if !sub.Enabled {
+		return nil
+	}
+	cron, err := schedule.Parse(sub.Schedule)
+	if err != nil {
+		return nil
+	}
+	location, err := time.LoadLocation(sub.Timezone)
+	if err != nil {
+		location = time.UTC
+	}
+
+	next := cron.Next(now.In(location))
+	if next.IsZero() {
+		return nil
+	}
+	next = next.UTC()
+	return &next
+}
+
+// HandleListSubscriptions lists the user's subscriptions
+func HandleListSubscriptions(c *gin.Context) {
+	userID := c.GetUint("user_id")
+
+	var subscriptions []models.Subscription
+	if err := database.DB.Where("user_id = ?", userID).Order("created_at").Find(&subscriptions).Error; err != nil {
+		log.Printf("[Subscription Handler] Failed to list subscriptions: %v", err)
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to list subscriptions"})
+		return
+	}
+
+	responses := make([]subscriptionResponse, len(subscriptions))
+	for i := range subscriptions {
+		responses[i] = newSubscriptionResponse(&subscriptions[i])
+	}
+	c.JSON(http.StatusOK, gin.H{"subscriptions": responses})
+}
+
+// HandleCreateSubscription creates a subscription saving into a database of
+// the selected workspace
+func HandleCreateSubscription(c *gin.Context) {
+	userID := c.GetUint("user_id")
+
+	var req SubscriptionRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "name, subreddits, database_id and schedule are required", "details": err.Error()})
+		return
+	}
+
+	connection, ok := getNotionConnection(c, userID)
+	if !ok {
+		return
+	}
+
+	sub := models.Subscription{UserID: userID, WorkspaceID: connection.WorkspaceID}
+	if err := req.apply(&sub); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+
+	if err := database.DB.Create(&sub).Error; err != nil {
+		log.Printf("[Subscription Handler] Failed to create subscription: %v", err)
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to create subscription"})
+		return
+	}
+
+	log.Printf("[Subscription Handler] User %d created subscription %d (%s)", userID, sub.ID, sub.Schedule)
+	c.JSON(http.StatusCreated, newSubscriptionResponse(&sub))
+}
+
+// HandleGetSubscription returns one of the user's subscriptions
+func HandleGetSubscription(c *gin.Context) {
+	sub, ok := loadSubscription(c)
+	if !ok {
+		return
+	}
+	c.JSON(http.StatusOK, newSubscriptionResponse(sub))
+}
+
+// HandleUpdateSubscription replaces a subscription's settings. The workspace
+// changes only when workspace_id is passed.
+func HandleUpdateSubscription(c *gin.Context) {
+	sub, ok := loadSubscription(c)
+	if !ok {
+		return
+	}
+
+	var req SubscriptionRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "name, subreddits, database_id and schedule are required", "details": err.Error()})
+		return
+	}
+
+	if c.Query("workspace_id") != "" {
+		connection, ok := getNotionConnection(c, sub.UserID)
+		if !ok {
+			return
+		}
+		sub.WorkspaceID = connection.WorkspaceID
+	}
+
+	if err := req.apply(sub); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+
+	// Select all so cleared fields (keyword, filter) are written too
+	if err := database.DB.Select("*").Omit("created_at").Save(sub).Error; err != nil {
+		log.Printf("[Subscription Handler] Failed to update subscription %d: %v", sub.ID, err)
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to update subscription"})
+		return
+	}
+
+	c.JSON(http.StatusOK, newSubscriptionResponse(sub))
+}
+
+// HandleDeleteSubscription deletes a subscription and its run history
+func HandleDeleteSubscription(c *gin.Context) {
+	sub, ok := loadSubscription(c)
+	if !ok {
+		return
+	}
+
+	err := database.DB.Transaction(func(tx *gorm.DB) error {
+		if err := tx.Where("subscription_id = ?", sub.ID).Delete(&models.SubscriptionRun{}).Error; err != nil {
+			return err
+		}
+		return tx.Delete(sub).Error
+	})
+	if err != nil {
+		log.Printf("[Subscription Handler] Failed to delete subscription %d: %v", sub.ID, err)
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to delete subscription"})
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{"success": true})
+}
+
+// HandleListSubscriptionRuns returns a subscription's most recent runs with
+// the jobs that saved their posts
+func HandleListSubscriptionRuns(c *gin.Context) {
+	sub, ok := loadSubscription(c)
+	if !ok {
+		return
+	}
+
+	var runs []models.SubscriptionRun
+	err := database.DB.Where("subscription_id = ?", sub.ID).
+		Preload("Job").
+		Order("started_at DESC").
+		Limit(maxSubscriptionRuns).
+		Find(&runs).Error
+	if err != nil {
+		log.Printf("[Subscription Handler] Failed to list runs of subscription %d: %v", sub.ID, err)
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to list runs"})
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{"runs": runs})
+}
+
+// HandleRunSubscription runs a subscription now, outside its schedule
+func HandleRunSubscription(c *gin.Context) {
+	sub, ok := loadSubscription(c)
+	if !ok {
+		return
+	}
+
+	run := runSubscription(c.Request.Context(), sub)
+	if run.Status == RunFailed {
+		c.JSON(http.StatusBadGateway, gin.H{"error": "Subscription run failed", "details": run.Error, "run": run})
+		return
+	}
+
+	c.JSON(http.StatusOK, run)
+}
+
+// loadSubscription fetches the subscription named in the URL if it belongs
+// to the user, writing an error response otherwise
+func loadSubscription(c *gin.Context) (*models.Subscription, bool) {
+	id, err := strconv.ParseUint(c.Param("id"), 10, 64)
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "Invalid subscription ID"})
+		return nil, false
+	}
+
+	var sub models.Subscription
+	err = database.DB.Where("id = ? AND user_id = ?", id, c.GetUint("user_id")).First(&sub).Error
+	if errors.Is(err, gorm.ErrRecordNotFound) {
+		c.JSON(http.StatusNotFound, gin.H{"error": "Subscription not found"})
+		return nil, false
+	}
+	if err != nil {
+		log.Printf("[Subscription Handler] Failed to load subscription %d: %v", id, err)
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to load subscription"})
+		return nil, false
+	}
+	return &sub, true
+}
+
+// StartScheduler runs due subscriptions in the background, checking every interval
+func StartScheduler(interval time.Duration) {
+	go func() {
+		ticker := time.NewTicker(interval)
+		defer ticker.Stop()
+
+		for range ticker.C {
+			runDueSubscriptions(time.Now())
+		}
+	}()
+}
+
+// runDueSubscriptions starts every enabled subscription whose next run has come
+func runDueSubscriptions(now time.Time) {
+	var due []models.Subscription
+	if err := database.DB.Where("enabled AND next_run_at <= ?", now).Find(&due).Error; err != nil {
+		log.Printf("[Scheduler] Failed to look up due subscriptions: %v", err)
+		return
+	}
+
+	for i := range due {
+		sub := &due[i]
+		claimed, err := claimSubscriptionRun(sub, now)
+		if err != nil {
+			log.Printf("[Scheduler] Failed to claim run of subscription %d: %v", sub.ID, err)
+			continue
+		}
+		if claimed {
+			go runSubscription(context.Background(), sub)
+		}
+	}
+}
+
+// claimSubscriptionRun moves a due subscription's next_run_at on, reporting
+// whether this call did so. When several server instances see the same due
+// run, only the first update matches the row.
+func claimSubscriptionRun(sub *models.Subscription, now time.Time) (bool, error) {
+	claimed := database.DB.Model(&models.Subscription{}).
+		Where("id = ? AND next_run_at = ?", sub.ID, sub.NextRunAt).
+		Update("next_run_at", nextSubscriptionRun(sub, now))
+	return claimed.RowsAffected > 0, claimed.Error
+}
+
+// runSubscription fetches a subscription's posts and queues the ones not yet
+// saved to its database as a bulk save job, recording the run
+func runSubscription(ctx context.Context, sub *models.Subscription) *models.SubscriptionRun {
+	run := models.SubscriptionRun{SubscriptionID: sub.ID, Status: RunRunning, StartedAt: time.Now()}
+	if err := database.DB.Create(&run).Error; err != nil {
+		log.Printf("[Scheduler] Failed to record run of subscription %d: %v", sub.ID, err)
+	}
+
+	if err := fetchAndQueue(ctx, sub, &run); err != nil {
+		log.Printf("[Scheduler] Subscription %d failed: %v", sub.ID, err)
+		run.Status, run.Error = RunFailed, err.Error()
+	} else {
+		run.Status = RunCompleted
+		log.Printf("[Scheduler] Subscription %d fetched %d posts, queued %d new", sub.ID, run.Fetched, run.Queued)
+	}
+
+	now := time.Now()
+	run.FinishedAt = &now
+	if err := database.DB.Save(&run).Error; err != nil {
+		log.Printf("[Scheduler] Failed to record run of subscription %d: %v", sub.ID, err)
+	}
+	if err := database.DB.Model(sub).Update("last_run_at", &now).Error; err != nil {
+		log.Printf("[Scheduler] Failed to update subscription %d: %v", sub.ID, err)
+	}
+	return &run
+}
+
+// fetchAndQueue does the work of a run, filling in its counts and job
+func fetchAndQueue(ctx context.Context, sub *models.Subscription, run *models.SubscriptionRun) error {
+	var connection models.NotionConnection
+	if err := database.DB.Where("user_id = ? AND workspace_id = ?", sub.UserID, sub.WorkspaceID).First(&connection).Error; err != nil {
+		return fmt.Errorf("notion workspace is no longer connected")
+	}
+
+	fetchCtx, cancel := context.WithTimeout(ctx, fetchPostsDeadline)
+	defer cancel()
+
+	result := redditClient.FetchMulti(fetchCtx, reddit.MultiFetchParams{
+		Subreddits: sub.Subreddits,
+		Keyword:    sub.Keyword,
+		Sort:       sub.Sort,
+		TimeRange:  sub.TimeRange,
+		Limit:      sub.Limit,
+		Filter:     sub.Filter,
+	})
+	if result.AllFailed {
+		return fmt.Errorf("failed to fetch posts from Reddit: %w", result.Errors[0].Err)
+	}
+	run.Fetched = len(result.Posts)
+
+	posts, err := unsavedPosts(sub.UserID, sub.DatabaseID, result.Posts)
+	if err != nil {
+		return err
+	}
+	requests := make([]notion.SavePostRequest, len(posts))
+	for i := range posts {
+		requests[i] = postSaveRequest(&posts[i], sub.DatabaseID)
+	}
+	if len(requests) == 0 {
+		return nil
+	}
+
+	items, err := bulkSaveItems(requests, sub.DatabaseID, 0)
+	if err != nil {
+		return err
+	}
+	job, err := enqueueBulkSave(sub.UserID, connection.WorkspaceID, sub.DatabaseID, items)
+	if err != nil {
+		return err
+	}
+	run.Queued = len(items)
+	run.JobID = &job.ID
+	return nil
+}
+
+// unsavedPosts drops the posts the user already saved to databaseID, by a
+// subscription run or otherwise, including legacy saves with no database ID
+func unsavedPosts(userID uint, databaseID string, posts []reddit.RedditPost) ([]reddit.RedditPost, error) {
+	if len(posts) == 0 {
+		return nil, nil
+	}
+
+	ids := make([]string, len(posts))
+	for i, post := range posts {
+		ids[i] = post.ID
+	}
+	alreadySaved, err := savedRedditIDs(userID, databaseID, ids)
+	if err != nil {
+		return nil, err
+	}
+
+	unsaved := make([]reddit.RedditPost, 0, len(posts))
+	for _, post := range posts {
+		if !alreadySaved[post.ID] {
+			unsaved = append(unsaved, post)
+		}
+	}
+	return unsaved, nil
+}
@@ -0,0 +1,211 @@
+package handlers
+
+import (
+	"os"
+	"sync"
+	"testing"
+	"time"
+
+	"re2no/database"
+	"re2no/models"
+	"re2no/reddit"
+)
+
+// connectTestDB connects to the Postgres database in TEST_DATABASE_URL and
+// migrates it, skipping the test when none is configured
+func connectTestDB(t *testing.T) {
+	t.Helper()
+
+	dsn := os.Getenv("TEST_DATABASE_URL")
+	if dsn == "" {
+		t.Skip("TEST_DATABASE_URL is not set")
+	}
+	t.Setenv("DATABASE_URL", dsn)
+	if err := database.Connect(); err != nil {
+		t.Fatal(err)
+	}
+	if err := database.Migrate(); err != nil {
+		t.Fatal(err)
+	}
+	t.Cleanup(func() { database.Close() })
+}
+
+// createTestUser adds a user whose rows are deleted when the test ends
+func createTestUser(t *testing.T) *models.User {
+	t.Helper()
+
+	user := models.User{NotionUserID: "test-" + t.Name() + "-" + time.Now().Format(time.RFC3339Nano)}
+	if err := database.DB.Create(&user).Error; err != nil {
+		t.Fatal(err)
+	}
+	t.Cleanup(func() {
+		database.DB.Where("user_id = ?", user.ID).Delete(&models.Subscription{})
+		database.DB.Where("user_id = ?", user.ID).Delete(&models.RedditPost{})
+		database.DB.Unscoped().Delete(&user)
+	})
+	return &user
+}
+
+func TestNextSubscriptionRun(t *testing.T) {
+	now := time.Date(2026, 7, 1, 7, 30, 0, 0, time.UTC)
+
+	tests := []struct {
+		name string
+		sub  models.Subscription
+		want *time.Time
+	}{
+		{
+			name: "utc",
+			sub:  models.Subscription{Enabled: true, Schedule: "0 8 * * *", Timezone: "UTC"},
+			want: ptr(time.Date(2026, 7, 1, 8, 0, 0, 0, time.UTC)),
+		},
+		{
+			name: "read in the subscription's timezone",
+			sub:  models.Subscription{Enabled: true, Schedule: "0 8 * * *", Timezone: "Europe/Berlin"},
+			want: ptr(time.Date(2026, 7, 2, 6, 0, 0, 0, time.UTC)),
+		},
+		{
+			name: "unknown timezone falls back to utc",
+			sub:  models.Subscription{Enabled: true, Schedule: "0 8 * * *", Timezone: "Nowhere/Special"},
+			want: ptr(time.Date(2026, 7, 1, 8, 0, 0, 0, time.UTC)),
+		},
+		{
+			name: "disabled",
+			sub:  models.Subscription{Enabled: false, Schedule: "0 8 * * *", Timezone: "UTC"},
+		},
+		{
+			name: "schedule never matches",
+			sub:  models.Subscription{Enabled: true, Schedule: "0 0 30 2 *", Timezone: "UTC"},
+		},
+		{
+			name: "invalid schedule",
+			sub:  models.Subscription{Enabled: true, Schedule: "tomorrow", Timezone: "UTC"},
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got := nextSubscriptionRun(&tt.sub, now)
+			switch {
+			case got == nil && tt.want == nil:
+			case got == nil || tt.want == nil || !got.Equal(*tt.want):
+				t.Errorf("nextSubscriptionRun() = %v, want %v", got, tt.want)
+			case got.Location() != time.UTC:
+				t.Errorf("nextSubscriptionRun() = %v, want it in UTC", got)
+			}
+		})
+	}
+}
+
+func TestClaimSubscriptionRunOnce(t *testing.T) {
+	connectTestDB(t)
+	user := createTestUser(t)
+
+	due := time.Now().Add(-time.Minute).UTC().Truncate(time.Microsecond)
+	sub := models.Subscription{
+		UserID:      user.ID,
+		Name:        "claim race",
+		Subreddits:  []string{"golang"},
+		Sort:        "new",
+		Limit:       25,
+		WorkspaceID: "workspace",
+		DatabaseID:  "database",
+		Schedule:    "* * * * *",
+		Timezone:    "UTC",
+		Enabled:     true,
+		NextRunAt:   &due,
+	}
+	if err := database.DB.Create(&sub).Error; err != nil {
+		t.Fatal(err)
+	}
+
+	// Every scheduler saw the same due row; only one may run it
+	const schedulers = 8
+	var (
+		wg     sync.WaitGroup
+		mu     sync.Mutex
+		claims int
+	)
+	for i := 0; i < schedulers; i++ {
+		wg.Add(1)
+		go func(seen models.Subscription) {
+			defer wg.Done()
+			claimed, err := claimSubscriptionRun(&seen, time.Now())
+			if err != nil {
+				t.Error(err)
+				return
+			}
+			if claimed {
+				mu.Lock()
+				claims++
+				mu.Unlock()
+			}
+		}(sub)
+	}
+	wg.Wait()
+
+	if claims != 1 {
+		t.Fatalf("%d schedulers claimed the run, want 1", claims)
+	}
+
+	var stored models.Subscription
+	if err := database.DB.First(&stored, sub.ID).Error; err != nil {
+		t.Fatal(err)
+	}
+	if stored.NextRunAt == nil || !stored.NextRunAt.After(due) {
+		t.Errorf("next_run_at = %v, want it moved past %v", stored.NextRunAt, due)
+	}
+
+	// The stale copy can't claim again either
+	if claimed, err := claimSubscriptionRun(&sub, time.Now()); err != nil || claimed {
+		t.Errorf("claiming a run already claimed = %v, %v; want false, nil", claimed, err)
+	}
+}
+
+func TestUnsavedPosts(t *testing.T) {
+	connectTestDB(t)
+	user := createTestUser(t)
+	other := createTestUser(t)
+
+	saved := []models.RedditPost{
+		{UserID: user.ID, RedditID: "saved", NotionDatabaseID: "database"},
+		{UserID: user.ID, RedditID: "elsewhere", NotionDatabaseID: "other-database"},
+		{UserID: user.ID, RedditID: "legacy", NotionDatabaseID: ""},
+		{UserID: other.ID, RedditID: "by-someone-else", NotionDatabaseID: "database"},
+	}
+	for i := range saved {
+		saved[i].Title, saved[i].Subreddit, saved[i].URL = "title", "golang", "https://reddit.com"
+		saved[i].NotionPageID = "page-" + saved[i].RedditID
+		if err := database.DB.Create(&saved[i]).Error; err != nil {
+			t.Fatal(err)
+		}
+	}
+
+	posts := []reddit.RedditPost{{ID: "new"}, {ID: "saved"}, {ID: "elsewhere"}, {ID: "by-someone-else"}, {ID: "legacy"}}
+	unsaved, err := unsavedPosts(user.ID, "database", posts)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	var got []string
+	for _, post := range unsaved {
+		got = append(got, post.ID)
+	}
+	want := []string{"new", "elsewhere", "by-someone-else"}
+	if len(got) != len(want) {
+		t.Fatalf("unsavedPosts() = %v, want %v", got, want)
+	}
+	for i := range want {
+		if got[i] != want[i] {
+			t.Fatalf("unsavedPosts() = %v, want %v", got, want)
+		}
+	}
+
+	if unsaved, err := unsavedPosts(user.ID, "database", nil); err != nil || len(unsaved) != 0 {
+		t.Errorf("unsavedPosts(nil) = %v, %v; want nothing", unsaved, err)
+	}
+}
+
+func ptr[T any](v T) *T {
+	return &v
+}
@@ -0,0 +1,126 @@
+package handlers
+
+import (
+	"errors"
+	"log"
+	"net/http"
+	"re2no/auth"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/gin-gonic/gin"
+)
+
+// CreateAPITokenRequest is the body of POST /api/auth/tokens
+type CreateAPITokenRequest struct {
+	Name          string `json:"name" binding:"required"`
+	Scope         string `json:"scope" binding:"required"`
+	ExpiresInDays int    `json:"expires_in_days"` // 0 means the token never expires
+}
+
+// HandleCreateAPIToken creates a personal access token for scripts and cron jobs.
+// The plaintext token is only returned in this response.
+func HandleCreateAPIToken(c *gin.Context) {
+	userID := c.GetUint("user_id")
+
+	var req CreateAPITokenRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{
+			"error": "invalid request body",
+		})
+		return
+	}
+
+	req.Name = strings.TrimSpace(req.Name)
+	if req.Name == "" || len(req.Name) > 100 {
+		c.JSON(http.StatusBadRequest, gin.H{
+			"error": "name must be between 1 and 100 characters",
+		})
+		return
+	}
+	if req.ExpiresInDays < 0 {
+		c.JSON(http.StatusBadRequest, gin.H{
+			"error": "expires_in_days must not be negative",
+		})
+		return
+	}
+
+	var expiresAt *time.Time
+	if req.ExpiresInDays > 0 {
+		t := time.Now().AddDate(0, 0, req.ExpiresInDays)
+		expiresAt = &t
+	}
+
+	token, apiToken, err := auth.CreateAPIToken(userID, req.Name, req.Scope, expiresAt)
+	if err != nil {
+		if errors.Is(err, auth.ErrInvalidScope) {
+			c.JSON(http.StatusBadRequest, gin.H{
+				"error": err.Error(),
+			})
+			return
+		}
+		log.Printf("[Tokens Handler] Failed to create API token: %v", err)
+		c.JSON(http.StatusInternalServerError, gin.H{
+			"error": "failed to create API token",
+		})
+		return
+	}
+
+	log.Printf("[Tokens Handler] User %d created API token %d (%s scope)", userID, apiToken.ID, apiToken.Scope)
+
+	c.JSON(http.StatusCreated, gin.H{
+		"token":     token,
+		"api_token": apiToken,
+	})
+}
+
+// HandleListAPITokens lists the user's active API tokens (without their secrets)
+func HandleListAPITokens(c *gin.Context) {
+	userID := c.GetUint("user_id")
+
+	tokens, err := auth.ListAPITokens(userID)
+	if err != nil {
+		log.Printf("[Tokens Handler] Failed to list API tokens: %v", err)
+		c.JSON(http.StatusInternalServerError, gin.H{
+			"error": "failed to list API tokens",
+		})
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{
+		"tokens": tokens,
+		"count":  len(tokens),
+	})
+}
+
+// HandleRevokeAPIToken revokes one of the user's API tokens
+func HandleRevokeAPIToken(c *gin.Context) {
+	userID := c.GetUint("user_id")
+
+	tokenID, err := strconv.ParseUint(c.Param("id"), 10, 64)
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{
+			"error": "invalid token ID",
+		})
+		return
+	}
+
+	if err := auth.RevokeAPIToken(userID, uint(tokenID)); err != nil {
+		if errors.Is(err, auth.ErrInvalidAPIToken) {
+			c.JSON(http.StatusNotFound, gin.H{
+				"error": "API token not found",
+			})
+			return
+		}
+		log.Printf("[Tokens Handler] Failed to revoke API token %d: %v", tokenID, err)
+		c.JSON(http.StatusInternalServerError, gin.H{
+			"error": "failed to revoke API token",
+		})
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{
+		"message": "API token revoked",
+	})
+}
@@ -0,0 +1,77 @@
+package handlers
+
+import (
+	"log"
+	"net/http"
+	"re2no/database"
+	"re2no/models"
+
+	"github.com/gin-gonic/gin"
+)
+
+// getNotionConnection resolves the workspace a /api/notion request targets.
+// The workspace_id query parameter selects one of the user's connections;
+// without it the most recently authorized workspace is used. On failure the
+// error response has already been written.
+func getNotionConnection(c *gin.Context, userID uint) (*models.NotionConnection, bool) {
+	workspaceID := c.Query("workspace_id")
+	query := database.DB.Where("user_id = ?", userID)
+	if workspaceID != "" {
+		query = query.Where("workspace_id = ?", workspaceID)
+	}
+
+	var connection models.NotionConnection
+	if err := query.Order("updated_at DESC").First(&connection).Error; err != nil {
+		if workspaceID != "" {
+			log.Printf("[Notion Handler] Workspace %s not connected for user %d: %v", workspaceID, userID, err)
+			c.JSON(http.StatusNotFound, gin.H{"error": "Workspace not connected"})
+			return nil, false
+		}
+		log.Printf("[Notion Handler] No workspace connected for user %d: %v", userID, err)
+		c.JSON(http.StatusUnauthorized, gin.H{"error": "No Notion workspace connected. Please login again."})
+		return nil, false
+	}
+
+	return &connection, true
+}
+
+// HandleListWorkspaces lists the Notion workspaces the user has connected
+func HandleListWorkspaces(c *gin.Context) {
+	userID := c.GetUint("user_id")
+
+	var connections []models.NotionConnection
+	if err := database.DB.Where("user_id = ?", userID).Order("updated_at DESC").Find(&connections).Error; err != nil {
+		log.Printf("[Workspace Handler] Failed to list workspaces: %v", err)
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to retrieve workspaces"})
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{
+		"workspaces": connections,
+	})
+}
+
+// HandleDisconnectWorkspace removes a single workspace connection and its token.
+// Posts saved to that workspace stay in the history.
+func HandleDisconnectWorkspace(c *gin.Context) {
+	userID := c.GetUint("user_id")
+	workspaceID := c.Param("workspace_id")
+
+	result := database.DB.Where("user_id = ? AND workspace_id = ?", userID, workspaceID).Delete(&models.NotionConnection{})
+	if result.Error != nil {
+		log.Printf("[Workspace Handler] Failed to disconnect workspace %s: %v", workspaceID, result.Error)
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to disconnect workspace"})
+		return
+	}
+	if result.RowsAffected == 0 {
+		c.JSON(http.StatusNotFound, gin.H{"error": "Workspace not connected"})
+		return
+	}
+
+	log.Printf("[Workspace Handler] User %d disconnected workspace %s", userID, workspaceID)
+
+	c.JSON(http.StatusOK, gin.H{
+		"success": true,
+		"message": "Workspace disconnected",
+	})
+}
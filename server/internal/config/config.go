@@ -0,0 +1,23 @@
+// Package config reads settings from the environment
+package config
+
+import (
+	"log"
+	"os"
+	"time"
+)
+
+// Duration parses a Go duration such as "30s" from the environment variable
+// name, keeping fallback when it is unset or invalid
+func Duration(name string, fallback time.Duration) time.Duration {
+	value := os.Getenv(name)
+	if value == "" {
+		return fallback
+	}
+	d, err := time.ParseDuration(value)
+	if err != nil || d <= 0 {
+		log.Printf("[Config] Ignoring invalid %s=%q, using %s", name, value, fallback)
+		return fallback
+	}
+	return d
+}
@@ -0,0 +1,77 @@
+// Package httpretry holds the helpers shared by the Reddit and Notion
+// transports for repeating failed requests
+package httpretry
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"math"
+	"math/rand"
+	"net"
+	"net/http"
+	"strconv"
+	"time"
+)
+
+// Backoff returns the delay before retry attempt+1: exponential from base,
+// capped at max, with full jitter
+func Backoff(attempt int, base, max time.Duration) time.Duration {
+	ceiling := float64(base) * math.Pow(2, float64(attempt))
+	if ceiling > float64(max) {
+		ceiling = float64(max)
+	}
+	return time.Duration(rand.Int63n(int64(ceiling) + 1))
+}
+
+// Rewind clones a request for a retry, resetting its body if it has one
+func Rewind(req *http.Request) (*http.Request, error) {
+	clone := req.Clone(req.Context())
+	if req.Body == nil || req.Body == http.NoBody {
+		return clone, nil
+	}
+	if req.GetBody == nil {
+		return nil, fmt.Errorf("cannot retry request with a non-rewindable body")
+	}
+	body, err := req.GetBody()
+	if err != nil {
+		return nil, err
+	}
+	clone.Body = body
+	return clone, nil
+}
+
+// RetryAfter reads a delay in seconds from the first of the named headers
+// that holds one
+func RetryAfter(header http.Header, names ...string) (time.Duration, bool) {
+	for _, name := range names {
+		if seconds, err := strconv.ParseFloat(header.Get(name), 64); err == nil && seconds >= 0 {
+			return time.Duration(seconds * float64(time.Second)), true
+		}
+	}
+	return 0, false
+}
+
+// Sleep waits for d or until ctx is done
+func Sleep(ctx context.Context, d time.Duration) error {
+	timer := time.NewTimer(d)
+	defer timer.Stop()
+	select {
+	case <-timer.C:
+		return nil
+	case <-ctx.Done():
+		return ctx.Err()
+	}
+}
+
+// Unsent reports whether a round trip failed before any of the request could
+// reach the server (the host didn't resolve or the connection was refused),
+// so repeating it can't apply it twice
+func Unsent(err error) bool {
+	var dnsErr *net.DNSError
+	if errors.As(err, &dnsErr) {
+		return true
+	}
+	var opErr *net.OpError
+	return errors.As(err, &opErr) && opErr.Op == "dial"
+}
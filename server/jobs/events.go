@@ -0,0 +1,129 @@
+package jobs
+
+import (
+	"encoding/json"
+	"log"
+	"sync"
+	"time"
+
+	"re2no/database"
+	"re2no/models"
+
+	"gorm.io/gorm"
+)
+
+// Event types
+const (
+	EventStarted  = "started"  // The job began (or resumed) processing
+	EventItem     = "item"     // An item started or finished
+	EventFinished = "finished" // The job is done; no events follow
+)
+
+// Progress is a job's counters, sent with every event
+type Progress struct {
+	Status    string `json:"status"`
+	Total     int    `json:"total"`
+	Succeeded int    `json:"succeeded"`
+	Skipped   int    `json:"skipped"`
+	Failed    int    `json:"failed"`
+}
+
+// ItemEvent is the data of an EventItem
+type ItemEvent struct {
+	Progress
+	ItemID        uint   `json:"item_id"`
+	Position      int    `json:"position"`
+	Key           string `json:"key"`
+	ItemStatus    string `json:"item_status"`
+	Error         string `json:"error,omitempty"`
+	NotionPageID  string `json:"notion_page_id,omitempty"`
+	NotionPageURL string `json:"notion_page_url,omitempty"`
+}
+
+// EventRetention is how long a finished job's events are kept for clients
+// resuming a stream. After that a stream of the job only gets its final
+// counts in a finished event.
+var EventRetention = 24 * time.Hour
+
+// subscribers holds, per job, channels signaled when the job stores an event
+var (
+	subscribersMu sync.Mutex
+	subscribers   = make(map[uint]map[chan struct{}]struct{})
+)
+
+// Subscribe returns a channel signaled whenever an event is stored for a job
+// in this process, and a function to stop listening. Signals are coalesced:
+// read new events with EventsSince after each one.
+func Subscribe(jobID uint) (<-chan struct{}, func()) {
+	ch := make(chan struct{}, 1)
+
+	subscribersMu.Lock()
+	if subscribers[jobID] == nil {
+		subscribers[jobID] = make(map[chan struct{}]struct{})
+	}
+	subscribers[jobID][ch] = struct{}{}
+	subscribersMu.Unlock()
+
+	return ch, func() {
+		subscribersMu.Lock()
+		defer subscribersMu.Unlock()
+		delete(subscribers[jobID], ch)
+		if len(subscribers[jobID]) == 0 {
+			delete(subscribers, jobID)
+		}
+	}
+}
+
+// notify signals a job's subscribers without blocking on slow readers
+func notify(jobID uint) {
+	subscribersMu.Lock()
+	defer subscribersMu.Unlock()
+	for ch := range subscribers[jobID] {
+		select {
+		case ch <- struct{}{}:
+		default:
+		}
+	}
+}
+
+// EventsSince returns a job's events stored after the event with ID afterID,
+// oldest first
+func EventsSince(jobID, afterID uint) ([]models.JobEvent, error) {
+	var events []models.JobEvent
+	err := database.DB.Where("job_id = ? AND id > ?", jobID, afterID).Order("id").Find(&events).Error
+	return events, err
+}
+
+// storeEvent saves an event in tx; call notify once tx commits
+func storeEvent(tx *gorm.DB, jobID uint, eventType string, data interface{}) error {
+	payload, err := json.Marshal(data)
+	if err != nil {
+		return err
+	}
+	return tx.Create(&models.JobEvent{JobID: jobID, Type: eventType, Data: string(payload)}).Error
+}
+
+// publish stores an event and signals subscribers. Events are best effort:
+// a failure is logged and doesn't stop the job.
+func publish(jobID uint, eventType string, data interface{}) {
+	if err := storeEvent(database.DB, jobID, eventType, data); err != nil {
+		log.Printf("[Jobs] Failed to store %s event for job %d: %v", eventType, jobID, err)
+		return
+	}
+	notify(jobID)
+}
+
+// progress reads a job's current counters
+func progress(tx *gorm.DB, jobID uint) (Progress, error) {
+	var p Progress
+	err := tx.Model(&models.Job{}).Select("status", "total", "succeeded", "skipped", "failed").Where("id = ?", jobID).Take(&p).Error
+	return p, err
+}
+
+// CleanupEvents deletes the events of jobs that finished more than
+// EventRetention ago
+func CleanupEvents() (int64, error) {
+	finished := database.DB.Model(&models.Job{}).Select("id").Where("finished_at < ?", time.Now().Add(-EventRetention))
+	result := database.DB.Where("job_id IN (?)", finished).Delete(&models.JobEvent{})
+	return result.RowsAffected, result.Error
+}
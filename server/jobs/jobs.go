@@ -0,0 +1,417 @@
+package jobs
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"log"
+	"sync"
+	"time"
+
+	"re2no/database"
+	"re2no/models"
+
+	"github.com/google/uuid"
+	"gorm.io/gorm"
+)
+
+// Job statuses
+const (
+	StatusPending   = "pending"
+	StatusRunning   = "running"
+	StatusCompleted = "completed" // Every item was processed, whether or not it succeeded
+	StatusFailed    = "failed"    // The job couldn't be processed at all
+)
+
+// Item statuses
+const (
+	ItemPending   = "pending"
+	ItemRunning   = "running"
+	ItemSucceeded = "succeeded"
+	ItemSkipped   = "skipped"
+	ItemFailed    = "failed"
+)
+
+// Concurrency is how many items of one job are processed at once. Requests to
+// Notion are paced per workspace regardless, so this mostly overlaps the
+// Reddit fetches with the Notion writes.
+var Concurrency = 3
+
+// Result is what processing an item produced
+type Result struct {
+	NotionPageID  string
+	NotionPageURL string
+	Skipped       bool // The item needed no work, e.g. the post was already saved
+}
+
+// Processor handles one item of a job
+type Processor func(ctx context.Context, job *models.Job, item *models.JobItem) (Result, error)
+
+// lockDuration is how long a claimed job stays with its instance without the
+// instance renewing the claim. Jobs of an instance that stops are taken over
+// by another once it lapses.
+const lockDuration = 2 * time.Minute
+
+var (
+	processors = make(map[string]Processor)
+
+	// instanceID identifies this process in the jobs it claims
+	instanceID = uuid.NewString()
+
+	// active holds the IDs of jobs being run by this process
+	active sync.Map
+)
+
+// Register sets the processor for a kind of job. Call it before Start.
+func Register(kind string, processor Processor) {
+	processors[kind] = processor
+}
+
+// Enqueue stores a job and its items and starts processing it in the background
+func Enqueue(job *models.Job, items []models.JobItem) error {
+	if _, ok := processors[job.Kind]; !ok {
+		return fmt.Errorf("no processor registered for %q jobs", job.Kind)
+	}
+
+	job.Status = StatusPending
+	job.Total = len(items)
+	err := database.DB.Transaction(func(tx *gorm.DB) error {
+		if err := tx.Create(job).Error; err != nil {
+			return err
+		}
+		for i := range items {
+			items[i].JobID = job.ID
+			items[i].Position = i
+			items[i].Status = ItemPending
+		}
+		if len(items) == 0 {
+			return nil
+		}
+		return tx.Create(&items).Error
+	})
+	if err != nil {
+		return fmt.Errorf("failed to store job: %w", err)
+	}
+
+	start(job.ID)
+	return nil
+}
+
+// Start resumes unclaimed jobs now and then every interval: jobs left pending
+// and jobs whose instance stopped renewing its claim, e.g. because it was
+// restarted. Each interval it also deletes events past EventRetention.
+func Start(interval time.Duration) {
+	Resume()
+	go func() {
+		ticker := time.NewTicker(interval)
+		defer ticker.Stop()
+
+		for range ticker.C {
+			Resume()
+			if deleted, err := CleanupEvents(); err != nil {
+				log.Printf("[Jobs] Failed to remove old job events: %v", err)
+			} else if deleted > 0 {
+				log.Printf("[Jobs] Removed %d old job events", deleted)
+			}
+		}
+	}()
+}
+
+// Resume starts every unfinished job no instance is running. Items that were
+// in flight are processed again; processors must tolerate that.
+func Resume() {
+	var ids []uint
+	err := database.DB.Model(&models.Job{}).
+		Where("status = ? OR (status = ? AND (locked_until IS NULL OR locked_until < ?))", StatusPending, StatusRunning, time.Now()).
+		Pluck("id", &ids).Error
+	if err != nil {
+		log.Printf("[Jobs] Failed to look up unfinished jobs: %v", err)
+		return
+	}
+
+	for _, id := range ids {
+		start(id)
+	}
+}
+
+// start runs a job in the background if this process can claim it
+func start(jobID uint) {
+	if _, running := active.LoadOrStore(jobID, struct{}{}); running {
+		return
+	}
+
+	go func() {
+		defer active.Delete(jobID)
+
+		claimed, err := claim(jobID)
+		if err != nil {
+			log.Printf("[Jobs] Failed to claim job %d: %v", jobID, err)
+			return
+		}
+		if !claimed {
+			return // Another instance is running it
+		}
+
+		if err := run(context.Background(), jobID); err != nil {
+			log.Printf("[Jobs] Job %d failed: %v", jobID, err)
+			release(jobID)
+		}
+	}()
+}
+
+// claim marks a job running on this instance unless another instance holds
+// it. When several instances claim the same job, only one update matches.
+func claim(jobID uint) (bool, error) {
+	now := time.Now()
+	claimed := database.DB.Model(&models.Job{}).
+		Where("id = ? AND (status = ? OR (status = ? AND (locked_until IS NULL OR locked_until < ?)))", jobID, StatusPending, StatusRunning, now).
+		Updates(map[string]interface{}{
+			"status":       StatusRunning,
+			"locked_by":    instanceID,
+			"locked_until": now.Add(lockDuration),
+			"started_at":   gorm.Expr("COALESCE(started_at, ?)", now),
+		})
+	return claimed.RowsAffected > 0, claimed.Error
+}
+
+// renew extends this instance's claim on a job, reporting whether it still
+// holds it
+func renew(jobID uint) (bool, error) {
+	renewed := database.DB.Model(&models.Job{}).
+		Where("id = ? AND status = ? AND locked_by = ?", jobID, StatusRunning, instanceID).
+		Update("locked_until", time.Now().Add(lockDuration))
+	return renewed.RowsAffected > 0, renewed.Error
+}
+
+// release hands a job this instance couldn't finish back, for the next
+// Resume on any instance to pick up
+func release(jobID uint) {
+	err := database.DB.Model(&models.Job{}).
+		Where("id = ? AND status = ? AND locked_by = ?", jobID, StatusRunning, instanceID).
+		Updates(map[string]interface{}{"status": StatusPending, "locked_by": "", "locked_until": nil}).Error
+	if err != nil {
+		log.Printf("[Jobs] Failed to release job %d: %v", jobID, err)
+	}
+}
+
+// keepClaim renews this instance's claim on a job until ctx is done, calling
+// lost if another instance took the job over
+func keepClaim(ctx context.Context, jobID uint, lost func()) {
+	ticker := time.NewTicker(lockDuration / 4)
+	defer ticker.Stop()
+
+	for {
+		select {
+		case <-ctx.Done():
+			return
+		case <-ticker.C:
+			held, err := renew(jobID)
+			if err != nil {
+				// The claim outlasts a few failed renewals
+				log.Printf("[Jobs] Failed to renew claim on job %d: %v", jobID, err)
+				continue
+			}
+			if !held {
+				log.Printf("[Jobs] Lost claim on job %d, stopping", jobID)
+				lost()
+				return
+			}
+		}
+	}
+}
+
+// run processes the pending items of a job this instance claimed, with
+// bounded concurrency
+func run(ctx context.Context, jobID uint) error {
+	ctx, cancel := context.WithCancel(ctx)
+	defer cancel()
+	go keepClaim(ctx, jobID, cancel)
+
+	var job models.Job
+	if err := database.DB.First(&job, jobID).Error; err != nil {
+		return err
+	}
+
+	processor, ok := processors[job.Kind]
+	if !ok {
+		finish(&job, StatusFailed)
+		return fmt.Errorf("no processor registered for %q jobs", job.Kind)
+	}
+
+	// Items left running were in flight on an instance that stopped
+	if err := database.DB.Model(&models.JobItem{}).Where("job_id = ? AND status = ?", job.ID, ItemRunning).Update("status", ItemPending).Error; err != nil {
+		return fmt.Errorf("failed to reset in-flight items: %w", err)
+	}
+	if p, err := progress(database.DB, job.ID); err == nil {
+		publish(job.ID, EventStarted, p)
+	}
+
+	var items []models.JobItem
+	if err := database.DB.Where("job_id = ? AND status = ?", job.ID, ItemPending).Order("position").Find(&items).Error; err != nil {
+		return err
+	}
+
+	log.Printf("[Jobs] Running %s job %d: %d of %d items left", job.Kind, job.ID, len(items), job.Total)
+
+	queue := make(chan *models.JobItem)
+	var wg sync.WaitGroup
+	for w := 0; w < Concurrency; w++ {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			for item := range queue {
+				process(ctx, &job, item, processor)
+			}
+		}()
+	}
+	for i := range items {
+		if ctx.Err() != nil {
+			break // The claim was lost; the instance that has the job now does the rest
+		}
+		queue <- &items[i]
+	}
+	close(queue)
+	wg.Wait()
+	if ctx.Err() != nil {
+		return fmt.Errorf("stopped: the job was taken over by another instance")
+	}
+
+	// Items whose outcome couldn't be recorded are still pending or running;
+	// the job isn't complete until they are processed again
+	var unfinished int64
+	if err := database.DB.Model(&models.JobItem{}).Where("job_id = ? AND status IN ?", job.ID, []string{ItemPending, ItemRunning}).Count(&unfinished).Error; err != nil {
+		return fmt.Errorf("failed to check for unfinished items: %w", err)
+	}
+	if unfinished > 0 {
+		return fmt.Errorf("%d items are unfinished, leaving the job to be resumed", unfinished)
+	}
+
+	finish(&job, StatusCompleted)
+	log.Printf("[Jobs] Finished %s job %d", job.Kind, job.ID)
+	return nil
+}
+
+// process runs one item and records its outcome on the item and the job's counters
+func process(ctx context.Context, job *models.Job, item *models.JobItem, processor Processor) {
+	var result Result
+	item.Status = ItemRunning
+	err := database.DB.Model(item).Update("status", item.Status).Error
+	if err != nil {
+		// Record the item as failed rather than leave it pending on a job
+		// that would then never complete
+		log.Printf("[Jobs] Failed to mark item %d of job %d running: %v", item.ID, job.ID, err)
+		err = fmt.Errorf("failed to start processing: %w", err)
+	} else {
+		if p, err := progress(database.DB, job.ID); err == nil {
+			publish(job.ID, EventItem, itemEvent(p, item))
+		}
+		result, err = processor(ctx, job, item)
+		if err != nil && ctx.Err() != nil {
+			return // The job was taken over; the instance that has it redoes the item
+		}
+	}
+
+	item.NotionPageID, item.NotionPageURL, item.Error = result.NotionPageID, result.NotionPageURL, ""
+	counter := "succeeded"
+	switch {
+	case err != nil:
+		item.Status, item.Error = ItemFailed, err.Error()
+		counter = "failed"
+		log.Printf("[Jobs] Item %s of job %d failed: %v", item.Key, job.ID, err)
+	case result.Skipped:
+		item.Status = ItemSkipped
+		counter = "skipped"
+	default:
+		item.Status = ItemSucceeded
+	}
+
+	err = database.DB.Transaction(func(tx *gorm.DB) error {
+		if err := tx.Model(item).Select("status", "error", "notion_page_id", "notion_page_url").Updates(item).Error; err != nil {
+			return err
+		}
+		// Workers share job, so count in SQL rather than through the struct
+		if err := tx.Model(&models.Job{}).Where("id = ?", job.ID).UpdateColumn(counter, gorm.Expr(counter+" + 1")).Error; err != nil {
+			return err
+		}
+		p, err := progress(tx, job.ID)
+		if err != nil {
+			return err
+		}
+		return storeEvent(tx, job.ID, EventItem, itemEvent(p, item))
+	})
+	if err != nil {
+		log.Printf("[Jobs] Failed to record outcome of item %d of job %d: %v", item.ID, job.ID, err)
+		return
+	}
+	notify(job.ID)
+}
+
+// itemEvent describes an item's current state for subscribers
+func itemEvent(p Progress, item *models.JobItem) ItemEvent {
+	return ItemEvent{
+		Progress:      p,
+		ItemID:        item.ID,
+		Position:      item.Position,
+		Key:           item.Key,
+		ItemStatus:    item.Status,
+		Error:         item.Error,
+		NotionPageID:  item.NotionPageID,
+		NotionPageURL: item.NotionPageURL,
+	}
+}
+
+// finish marks a job done. The finished event is stored with the status so
+// a job that reads as done always has one.
+func finish(job *models.Job, status string) {
+	now := time.Now()
+	err := database.DB.Transaction(func(tx *gorm.DB) error {
+		updated := tx.Model(job).Where("locked_by = ?", instanceID).
+			Updates(map[string]interface{}{"status": status, "finished_at": &now, "locked_by": "", "locked_until": nil})
+		if updated.Error != nil {
+			return updated.Error
+		}
+		if updated.RowsAffected == 0 {
+			return fmt.Errorf("the job is claimed by another instance")
+		}
+		p, err := progress(tx, job.ID)
+		if err != nil {
+			return err
+		}
+		return storeEvent(tx, job.ID, EventFinished, p)
+	})
+	if err != nil {
+		log.Printf("[Jobs] Failed to mark job %d %s: %v", job.ID, status, err)
+		return
+	}
+	notify(job.ID)
+}
+
+// IsDone reports whether a job status is final
+func IsDone(status string) bool {
+	return status == StatusCompleted || status == StatusFailed
+}
+
+// ErrNotFound is returned by Get when the user has no job with that ID
+var ErrNotFound = errors.New("job not found")
+
+// Find loads one of a user's jobs without its items
+func Find(userID, jobID uint) (*models.Job, error) {
+	var job models.Job
+	err := database.DB.Where("id = ? AND user_id = ?", jobID, userID).First(&job).Error
+	if errors.Is(err, gorm.ErrRecordNotFound) {
+		return nil, ErrNotFound
+	}
+	return &job, err
+}
+
+// Get loads one of a user's jobs with its items in order
+func Get(userID, jobID uint) (*models.Job, error) {
+	var job models.Job
+	err := database.DB.Where("id = ? AND user_id = ?", jobID, userID).
+		Preload("Items", func(db *gorm.DB) *gorm.DB { return db.Order("position") }).
+		First(&job).Error
+	if errors.Is(err, gorm.ErrRecordNotFound) {
+		return nil, ErrNotFound
+	}
+	return &job, err
+}
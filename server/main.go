@@ -6,8 +6,13 @@ import (
 	"os"
 	"re2no/auth"
 	"re2no/database"
+	"re2no/encryption"
 	"re2no/handlers"
+	"re2no/jobs"
 	"re2no/middleware"
+	"re2no/notion"
+	"re2no/reddit"
+	"time"
 
 	"github.com/gin-gonic/gin"
 	"github.com/joho/godotenv"
@@ -19,6 +24,9 @@ func main() {
 		log.Println("Warning: .env file not found, using environment variables")
 	}
 
+	// Initialize token encryption keys (must happen before any session is read or written)
+	encryption.Init()
+
 	// Initialize database connection
 	if err := database.Connect(); err != nil {
 		log.Fatalf("Failed to connect to database: %v", err)
@@ -36,6 +44,23 @@ func main() {
 	// Initialize Notion OAuth
 	auth.InitNotionOAuth()
 
+	// Initialize Reddit API access (app-only OAuth when credentials are set)
+	reddit.Init()
+
+	// Read Notion API timeouts
+	notion.Init()
+
+	// Run background jobs, picking up any interrupted by a restart or left
+	// behind by a stopped instance
+	handlers.RegisterJobProcessors()
+	jobs.Start(time.Minute)
+
+	// Run saved searches on their schedules
+	handlers.StartScheduler(time.Minute)
+
+	// Periodically purge OAuth states and login codes from abandoned logins
+	auth.StartCleanup(time.Hour)
+
 	router := gin.Default()
 
 	// CORS middleware - Allow credentials for authentication
@@ -64,7 +89,7 @@ func main() {
 			c.Writer.Header().Set("Access-Control-Allow-Origin", origin)
 			c.Writer.Header().Set("Access-Control-Allow-Credentials", "true")
 			c.Writer.Header().Set("Access-Control-Allow-Methods", "GET, POST, PUT, DELETE, OPTIONS")
-			c.Writer.Header().Set("Access-Control-Allow-Headers", "Content-Type, Authorization")
+			c.Writer.Header().Set("Access-Control-Allow-Headers", "Content-Type, Authorization, Last-Event-ID")
 		}
 
 		if c.Request.Method == "OPTIONS" {
@@ -84,32 +109,78 @@ func main() {
 	// Auth routes (public)
 	router.GET("/api/auth/notion/login", handlers.HandleNotionLogin)
 	router.GET("/api/auth/notion/callback", handlers.HandleNotionCallback)
-	router.POST("/api/auth/exchange-token", handlers.HandleExchangeToken) // Exchange URL token for cookie
+	router.POST("/api/auth/exchange-token", handlers.HandleExchangeToken) // Exchange login code for tokens
+	router.POST("/api/auth/refresh", handlers.HandleRefreshToken)         // Rotate refresh token for a new access token
+	router.POST("/api/auth/logout", handlers.HandleLogout)                // Works with just the refresh token once the access token expired
 
 	// Auth routes (protected)
 	authRoutes := router.Group("/api/auth")
 	authRoutes.Use(middleware.RequireAuth())
 	{
 		authRoutes.GET("/user", handlers.HandleGetUser)
-		authRoutes.POST("/logout", handlers.HandleLogout)
+	}
+
+	// Account management (browser sessions only, not API tokens)
+	accountRoutes := router.Group("/api/auth")
+	accountRoutes.Use(middleware.RequireAuth(), middleware.RequireSession())
+	{
+		accountRoutes.GET("/sessions", handlers.HandleListLogins)
+		accountRoutes.DELETE("/sessions", handlers.HandleRevokeAllLogins)
+		accountRoutes.DELETE("/sessions/:id", handlers.HandleRevokeLogin)
+		accountRoutes.GET("/tokens", handlers.HandleListAPITokens)
+		accountRoutes.POST("/tokens", handlers.HandleCreateAPIToken)
+		accountRoutes.DELETE("/tokens/:id", handlers.HandleRevokeAPIToken)
 	}
 
 	// Reddit routes (protected)
 	redditRoutes := router.Group("/api/reddit")
 	redditRoutes.Use(middleware.RequireAuth())
 	{
-		redditRoutes.GET("/posts", handlers.HandleFetchPosts)
+		redditRoutes.GET("/posts", middleware.RequireScope(auth.ScopeRead), handlers.HandleFetchPosts)
+		redditRoutes.GET("/posts/:id/comments", middleware.RequireScope(auth.ScopeRead), handlers.HandleFetchComments)
+		redditRoutes.GET("/connection", middleware.RequireScope(auth.ScopeRead), handlers.HandleGetRedditConnection)
+		redditRoutes.POST("/connect", middleware.RequireSession(), handlers.HandleRedditConnect)
+		redditRoutes.POST("/connect/callback", middleware.RequireSession(), handlers.HandleRedditCallback)
+		redditRoutes.DELETE("/connection", middleware.RequireSession(), handlers.HandleDisconnectReddit)
+		redditRoutes.POST("/saved/import", middleware.RequireScope(auth.ScopeWrite), handlers.HandleImportSaved)
 	}
 
 	// Notion routes (protected)
 	notionRoutes := router.Group("/api/notion")
 	notionRoutes.Use(middleware.RequireAuth())
 	{
-		notionRoutes.POST("/save", handlers.HandleSaveToNotion)
-		notionRoutes.GET("/databases", handlers.HandleGetDatabases)
-		notionRoutes.GET("/saved-posts", handlers.HandleGetSavedPosts)
-		notionRoutes.DELETE("/saved-posts/:reddit_id", handlers.HandleDeleteSavedPost)
-		notionRoutes.POST("/create-database", handlers.HandleCreateRedditDatabase)
+		notionRoutes.POST("/save", middleware.RequireScope(auth.ScopeWrite), handlers.HandleSaveToNotion)
+		notionRoutes.POST("/save/bulk", middleware.RequireScope(auth.ScopeWrite), handlers.HandleBulkSave)
+		notionRoutes.GET("/databases", middleware.RequireScope(auth.ScopeRead), handlers.HandleGetDatabases)
+		notionRoutes.GET("/databases/:id/mapping", middleware.RequireScope(auth.ScopeRead), handlers.HandleGetDatabaseMapping)
+		notionRoutes.PUT("/databases/:id/mapping", middleware.RequireScope(auth.ScopeWrite), handlers.HandlePutDatabaseMapping)
+		notionRoutes.GET("/databases/:id/validate", middleware.RequireScope(auth.ScopeRead), handlers.HandleValidateDatabaseSchema)
+		notionRoutes.GET("/saved-posts", middleware.RequireScope(auth.ScopeRead), handlers.HandleGetSavedPosts)
+		notionRoutes.DELETE("/saved-posts/:reddit_id", middleware.RequireScope(auth.ScopeWrite), handlers.HandleDeleteSavedPost)
+		notionRoutes.POST("/create-database", middleware.RequireScope(auth.ScopeWrite), handlers.HandleCreateRedditDatabase)
+		notionRoutes.GET("/workspaces", middleware.RequireScope(auth.ScopeRead), handlers.HandleListWorkspaces)
+		notionRoutes.DELETE("/workspaces/:workspace_id", middleware.RequireSession(), handlers.HandleDisconnectWorkspace)
+	}
+
+	// Background job routes (protected)
+	jobRoutes := router.Group("/api/jobs")
+	jobRoutes.Use(middleware.RequireAuth())
+	{
+		jobRoutes.GET("/:id", middleware.RequireScope(auth.ScopeRead), handlers.HandleGetJob)
+		jobRoutes.GET("/:id/events", middleware.RequireScope(auth.ScopeRead), handlers.HandleJobEvents)
+	}
+
+	// Subscription routes (protected)
+	subscriptionRoutes := router.Group("/api/subscriptions")
+	subscriptionRoutes.Use(middleware.RequireAuth())
+	{
+		subscriptionRoutes.GET("", middleware.RequireScope(auth.ScopeRead), handlers.HandleListSubscriptions)
+		subscriptionRoutes.POST("", middleware.RequireScope(auth.ScopeWrite), handlers.HandleCreateSubscription)
+		subscriptionRoutes.GET("/:id", middleware.RequireScope(auth.ScopeRead), handlers.HandleGetSubscription)
+		subscriptionRoutes.PUT("/:id", middleware.RequireScope(auth.ScopeWrite), handlers.HandleUpdateSubscription)
+		subscriptionRoutes.DELETE("/:id", middleware.RequireScope(auth.ScopeWrite), handlers.HandleDeleteSubscription)
+		subscriptionRoutes.GET("/:id/runs", middleware.RequireScope(auth.ScopeRead), handlers.HandleListSubscriptionRuns)
+		subscriptionRoutes.POST("/:id/run", middleware.RequireScope(auth.ScopeWrite), handlers.HandleRunSubscription)
 	}
 
 	port := os.Getenv("PORT")
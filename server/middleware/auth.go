@@ -10,7 +10,13 @@ import (
 	"github.com/gin-gonic/gin"
 )
 
-// RequireAuth is a middleware that validates JWT tokens
+// Values stored under "auth_method" in the request context
+const (
+	AuthMethodSession  = "session"
+	AuthMethodAPIToken = "api_token"
+)
+
+// RequireAuth is a middleware that validates JWT access tokens and personal API tokens
 func RequireAuth() gin.HandlerFunc {
 	return func(c *gin.Context) {
 		// Get token from Authorization header (for cross-domain)
@@ -32,9 +38,24 @@ func RequireAuth() gin.HandlerFunc {
 			}
 		}
 
-		// Validate token
+		// Personal access tokens are only accepted as Bearer credentials
+		if auth.IsAPIToken(tokenString) && authHeader != "" {
+			authenticateAPIToken(c, tokenString)
+			return
+		}
+
+		// Validate token (only short-lived access tokens are accepted here)
 		claims, err := auth.ValidateToken(tokenString)
 		if err != nil {
+			if auth.IsTokenExpired(err) {
+				// Tell the client to renew via /api/auth/refresh rather than log in again
+				c.JSON(http.StatusUnauthorized, gin.H{
+					"error": "token expired",
+					"code":  "token_expired",
+				})
+				c.Abort()
+				return
+			}
 			c.JSON(http.StatusUnauthorized, gin.H{
 				"error": "invalid or expired token",
 			})
@@ -42,6 +63,24 @@ func RequireAuth() gin.HandlerFunc {
 			return
 		}
 
+		// Reject tokens that were revoked (logout, session revocation, refresh token reuse)
+		revoked, err := auth.IsAccessTokenRevoked(claims)
+		if err != nil {
+			log.Printf("[Auth Middleware] Failed to check revocation: %v", err)
+			c.JSON(http.StatusInternalServerError, gin.H{
+				"error": "failed to validate token",
+			})
+			c.Abort()
+			return
+		}
+		if revoked {
+			c.JSON(http.StatusUnauthorized, gin.H{
+				"error": "token has been revoked",
+			})
+			c.Abort()
+			return
+		}
+
 		// Fetch user from database
 		var user models.User
 		if err := database.DB.Where("id = ?", claims.UserID).First(&user).Error; err != nil {
@@ -57,6 +96,74 @@ func RequireAuth() gin.HandlerFunc {
 		c.Set("user_id", claims.UserID)
 		c.Set("user_email", claims.Email)
 		c.Set("user", &user)
+		c.Set("login_id", claims.LoginID)
+		c.Set("token_claims", claims)
+		c.Set("auth_method", AuthMethodSession)
+		c.Set("token_scope", auth.ScopeWrite)
+
+		c.Next()
+	}
+}
+
+// authenticateAPIToken validates a personal access token and sets the same
+// context values as a browser session, with the token's scope
+func authenticateAPIToken(c *gin.Context, tokenString string) {
+	apiToken, err := auth.ValidateAPIToken(tokenString)
+	if err != nil {
+		c.JSON(http.StatusUnauthorized, gin.H{
+			"error": "invalid or expired API token",
+		})
+		c.Abort()
+		return
+	}
+
+	var user models.User
+	if err := database.DB.Where("id = ?", apiToken.UserID).First(&user).Error; err != nil {
+		log.Printf("[Auth Middleware] Failed to fetch user for API token %d: %v", apiToken.ID, err)
+		c.JSON(http.StatusUnauthorized, gin.H{
+			"error": "user not found",
+		})
+		c.Abort()
+		return
+	}
+
+	c.Set("user_id", user.ID)
+	c.Set("user_email", user.Email)
+	c.Set("user", &user)
+	c.Set("api_token_id", apiToken.ID)
+	c.Set("auth_method", AuthMethodAPIToken)
+	c.Set("token_scope", apiToken.Scope)
+
+	c.Next()
+}
+
+// RequireScope rejects requests whose credential doesn't grant the given scope.
+// Must run after RequireAuth.
+func RequireScope(scope string) gin.HandlerFunc {
+	return func(c *gin.Context) {
+		if !auth.ScopeAllows(c.GetString("token_scope"), scope) {
+			c.JSON(http.StatusForbidden, gin.H{
+				"error": "token does not have the " + scope + " scope",
+			})
+			c.Abort()
+			return
+		}
+
+		c.Next()
+	}
+}
+
+// RequireSession only allows browser sessions, so an API token can't be used
+// to mint further tokens or manage the account's logins. Must run after RequireAuth.
+func RequireSession() gin.HandlerFunc {
+	return func(c *gin.Context) {
+		if c.GetString("auth_method") != AuthMethodSession {
+			c.JSON(http.StatusForbidden, gin.H{
+				"error": "this endpoint requires a browser session",
+			})
+			c.Abort()
+			return
+		}
 
 		c.Next()
 	}
@@ -3,6 +3,9 @@ package models
 import (
 	"time"
 
+	"re2no/notion"
+	"re2no/reddit"
+
 	"gorm.io/gorm"
 )
 
@@ -20,17 +23,22 @@ type User struct {
 	DeletedAt     gorm.DeletedAt `gorm:"index" json:"-"`
 
 	// Relations
-	Sessions    []Session    `gorm:"foreignKey:UserID" json:"-"`
-	RedditPosts []RedditPost `gorm:"foreignKey:UserID" json:"-"`
+	Sessions          []Session          `gorm:"foreignKey:UserID" json:"-"`
+	NotionConnections []NotionConnection `gorm:"foreignKey:UserID" json:"-"`
+	RedditPosts       []RedditPost       `gorm:"foreignKey:UserID" json:"-"`
 }
 
+// Session held a user's single Notion access token.
+//
+// Deprecated: replaced by NotionConnection (one per workspace). Rows are copied
+// into notion_connections on startup and the table is no longer written.
 type Session struct {
 	ID           uint      `gorm:"primaryKey" json:"id"`
 	UserID       uint      `gorm:"not null;index" json:"user_id"`
-	AccessToken  string    `gorm:"type:text;not null" json:"-"` // Encrypted
+	AccessToken  string    `gorm:"type:text;not null;serializer:encrypted" json:"-"` // Encrypted
 	TokenType    string    `json:"token_type"`
 	ExpiresAt    time.Time `json:"expires_at"`
-	RefreshToken string    `gorm:"type:text" json:"-"` // Encrypted
+	RefreshToken string    `gorm:"type:text;serializer:encrypted" json:"-"` // Encrypted
 	CreatedAt    time.Time `json:"created_at"`
 	UpdatedAt    time.Time `json:"updated_at"`
 
@@ -38,28 +46,222 @@ type Session struct {
 	User User `gorm:"foreignKey:UserID" json:"-"`
 }
 
-type RedditPost struct {
+// NotionConnection is a Notion workspace a user has authorized; a user can connect many
+type NotionConnection struct {
 	ID            uint      `gorm:"primaryKey" json:"id"`
-	UserID        uint      `gorm:"not null;index" json:"user_id"`
-	RedditID      string    `gorm:"uniqueIndex;not null" json:"reddit_id"`
-	Subreddit     string    `gorm:"index" json:"subreddit"`
-	Title         string    `json:"title"`
-	Content       string    `gorm:"type:text" json:"content"`
-	Author        string    `json:"author"`
-	Score         int       `json:"score"`
-	URL           string    `json:"url"`
-	NotionPageID  string    `json:"notion_page_id"`  // ID of the Notion page created
-	NotionPageURL string    `json:"notion_page_url"` // URL to open the Notion page
-	SavedAt       time.Time `json:"saved_at"`
+	UserID        uint      `gorm:"not null;uniqueIndex:idx_notion_connection_user_workspace" json:"user_id"`
+	WorkspaceID   string    `gorm:"not null;uniqueIndex:idx_notion_connection_user_workspace" json:"workspace_id"`
+	WorkspaceName string    `json:"workspace_name"`
+	WorkspaceIcon string    `json:"workspace_icon"`
+	BotID         string    `gorm:"index" json:"bot_id"`
+	AccessToken   string    `gorm:"type:text;not null;serializer:encrypted" json:"-"` // Encrypted
+	TokenType     string    `json:"token_type"`
 	CreatedAt     time.Time `json:"created_at"`
+	UpdatedAt     time.Time `json:"updated_at"` // Last (re)authorized
+
+	// Relations
+	User User `gorm:"foreignKey:UserID" json:"-"`
+}
+
+// RedditConnection is the Reddit account a user has linked for importing their saved items
+type RedditConnection struct {
+	ID           uint      `gorm:"primaryKey" json:"id"`
+	UserID       uint      `gorm:"uniqueIndex;not null" json:"user_id"`
+	RedditUserID string    `gorm:"not null" json:"reddit_user_id"`
+	Username     string    `gorm:"not null" json:"username"`
+	AccessToken  string    `gorm:"type:text;not null;serializer:encrypted" json:"-"` // Encrypted
+	RefreshToken string    `gorm:"type:text;serializer:encrypted" json:"-"`          // Encrypted
+	TokenType    string    `json:"token_type"`
+	Scope        string    `json:"scope"`
+	ExpiresAt    time.Time `json:"expires_at"` // Access token expiry; refreshed automatically
+	CreatedAt    time.Time `json:"created_at"`
+	UpdatedAt    time.Time `json:"updated_at"`
+
+	// Relations
+	User User `gorm:"foreignKey:UserID" json:"-"`
+}
+
+type RedditPost struct {
+	ID               uint      `gorm:"primaryKey" json:"id"`
+	UserID           uint      `gorm:"not null;index;uniqueIndex:idx_reddit_post_destination" json:"user_id"`
+	RedditID         string    `gorm:"not null;uniqueIndex:idx_reddit_post_destination" json:"reddit_id"`
+	Subreddit        string    `gorm:"index" json:"subreddit"`
+	Title            string    `json:"title"`
+	Content          string    `gorm:"type:text" json:"content"`
+	Author           string    `json:"author"`
+	Score            int       `json:"score"`
+	URL              string    `json:"url"`
+	NotionPageID     string    `json:"notion_page_id"`                                                                        // ID of the Notion page created
+	NotionPageURL    string    `json:"notion_page_url"`                                                                       // URL to open the Notion page
+	NotionDatabaseID string    `gorm:"not null;default:'';uniqueIndex:idx_reddit_post_destination" json:"notion_database_id"` // Database the page was saved to
+	WorkspaceID      string    `gorm:"index" json:"workspace_id"`                                                             // Notion workspace the page was saved to
+	SavedAt          time.Time `json:"saved_at"`
+	CreatedAt        time.Time `json:"created_at"`
 
 	// Relations
 	User User `gorm:"foreignKey:UserID" json:"-"`
 }
 
 type OAuthState struct {
+	ID           uint      `gorm:"primaryKey" json:"id"`
+	State        string    `gorm:"uniqueIndex;not null" json:"state"`
+	Provider     string    `gorm:"not null;default:'notion'" json:"provider"` // "notion" (login) or "reddit" (account link)
+	UserID       *uint     `json:"user_id"`                                   // User linking an account; nil for logins
+	VerifierHash string    `gorm:"not null;default:''" json:"-"`              // SHA-256 of the verifier cookie
+	ExpiresAt    time.Time `gorm:"index;not null" json:"expires_at"`
+	CreatedAt    time.Time `json:"created_at"`
+}
+
+type AuthCode struct {
 	ID        uint      `gorm:"primaryKey" json:"id"`
-	State     string    `gorm:"uniqueIndex;not null" json:"state"`
+	CodeHash  string    `gorm:"uniqueIndex;not null" json:"-"` // SHA-256 of the one-time login code
+	UserID    uint      `gorm:"not null;index" json:"user_id"`
+	ExpiresAt time.Time `gorm:"index;not null" json:"expires_at"`
+	CreatedAt time.Time `json:"created_at"`
+}
+
+type RefreshToken struct {
+	ID        uint       `gorm:"primaryKey" json:"id"`
+	UserID    uint       `gorm:"not null;index" json:"user_id"`
+	FamilyID  string     `gorm:"not null;index" json:"family_id"` // All rotations of one login share a family
+	TokenHash string     `gorm:"uniqueIndex;not null" json:"-"`   // SHA-256 of the refresh token
+	ExpiresAt time.Time  `gorm:"index;not null" json:"expires_at"`
+	UsedAt    *time.Time `json:"used_at"`    // Set once the token has been rotated
+	RevokedAt *time.Time `json:"revoked_at"` // Set on logout or reuse detection
+	CreatedAt time.Time  `json:"created_at"`
+}
+
+// Login is one signed-in device/browser; its refresh tokens share its FamilyID
+type Login struct {
+	ID         uint       `gorm:"primaryKey" json:"id"`
+	UserID     uint       `gorm:"not null;index" json:"user_id"`
+	FamilyID   string     `gorm:"uniqueIndex;not null" json:"-"`
+	UserAgent  string     `json:"user_agent"`
+	IPAddress  string     `json:"ip_address"`
+	LastSeenAt time.Time  `json:"last_seen_at"`
+	ExpiresAt  time.Time  `gorm:"index;not null" json:"expires_at"`
+	RevokedAt  *time.Time `gorm:"index" json:"revoked_at"`
+	CreatedAt  time.Time  `json:"created_at"` // Issued at
+}
+
+// RevokedToken is an access token (by jti) rejected before its natural expiry
+type RevokedToken struct {
+	JTI       string    `gorm:"primaryKey" json:"jti"`
 	ExpiresAt time.Time `gorm:"index;not null" json:"expires_at"`
 	CreatedAt time.Time `json:"created_at"`
 }
+
+// APIToken is a user-managed personal access token for scripts and cron jobs.
+// Only a hash of the token is stored; the plaintext is shown once on creation.
+type APIToken struct {
+	ID         uint       `gorm:"primaryKey" json:"id"`
+	UserID     uint       `gorm:"index;not null" json:"user_id"`
+	Name       string     `gorm:"not null" json:"name"`
+	Prefix     string     `gorm:"not null" json:"prefix"` // First characters of the token, to help users tell tokens apart
+	TokenHash  string     `gorm:"uniqueIndex;not null" json:"-"`
+	Scope      string     `gorm:"not null" json:"scope"` // "read" or "write"
+	ExpiresAt  *time.Time `json:"expires_at"`            // nil means the token never expires
+	LastUsedAt *time.Time `json:"last_used_at"`
+	RevokedAt  *time.Time `json:"revoked_at"`
+	CreatedAt  time.Time  `json:"created_at"`
+}
+
+// DatabaseMapping is a user's choice of which Reddit field (or constant) fills
+// each property of a Notion database when saving posts to it
+type DatabaseMapping struct {
+	ID         uint                   `gorm:"primaryKey" json:"id"`
+	UserID     uint                   `gorm:"not null;uniqueIndex:idx_database_mapping_user_database" json:"user_id"`
+	DatabaseID string                 `gorm:"not null;uniqueIndex:idx_database_mapping_user_database" json:"database_id"`
+	Properties notion.PropertyMapping `gorm:"type:jsonb;not null;serializer:json" json:"properties"`
+	CreatedAt  time.Time              `json:"created_at"`
+	UpdatedAt  time.Time              `json:"updated_at"`
+}
+
+// Job is a background operation, such as a bulk save, processed item by item.
+// Jobs outlive the request that created them and resume after a restart.
+type Job struct {
+	ID          uint       `gorm:"primaryKey" json:"id"`
+	UserID      uint       `gorm:"not null;index" json:"user_id"`
+	Kind        string     `gorm:"not null" json:"kind"`         // e.g. "bulk_save"
+	Status      string     `gorm:"not null;index" json:"status"` // pending, running, completed or failed
+	WorkspaceID string     `json:"workspace_id"`                 // Notion workspace the job writes to
+	DatabaseID  string     `json:"database_id"`                  // Notion database the job writes to
+	Total       int        `gorm:"not null;default:0" json:"total"`
+	Succeeded   int        `gorm:"not null;default:0" json:"succeeded"`
+	Skipped     int        `gorm:"not null;default:0" json:"skipped"`
+	Failed      int        `gorm:"not null;default:0" json:"failed"`
+	StartedAt   *time.Time `json:"started_at"`
+	FinishedAt  *time.Time `json:"finished_at"`
+	LockedBy    string     `json:"-"` // Server instance running the job
+	LockedUntil *time.Time `json:"-"` // When another instance may take the job over, unless renewed
+	CreatedAt   time.Time  `json:"created_at"`
+	UpdatedAt   time.Time  `json:"updated_at"`
+
+	// Relations
+	Items []JobItem `gorm:"foreignKey:JobID;constraint:OnDelete:CASCADE" json:"items,omitempty"`
+}
+
+// JobItem is one unit of a job's work, such as one post to save
+type JobItem struct {
+	ID            uint      `gorm:"primaryKey" json:"id"`
+	JobID         uint      `gorm:"not null;index" json:"job_id"`
+	Position      int       `gorm:"not null" json:"position"`
+	Key           string    `json:"key"`                                      // What the item is about, e.g. a Reddit post ID
+	Payload       string    `gorm:"type:text" json:"-"`                       // JSON input for the job's processor
+	Status        string    `gorm:"not null;default:'pending'" json:"status"` // pending, running, succeeded, skipped or failed
+	Error         string    `gorm:"type:text" json:"error,omitempty"`
+	NotionPageID  string    `json:"notion_page_id,omitempty"`
+	NotionPageURL string    `json:"notion_page_url,omitempty"`
+	CreatedAt     time.Time `json:"created_at"`
+	UpdatedAt     time.Time `json:"updated_at"`
+}
+
+// JobEvent is a progress update of a job, kept so clients streaming it can
+// resume from the last event they saw
+type JobEvent struct {
+	ID        uint      `gorm:"primaryKey" json:"id"` // Sent as the SSE event ID
+	JobID     uint      `gorm:"not null;index" json:"job_id"`
+	Type      string    `gorm:"not null" json:"type"`  // started, item or finished
+	Data      string    `gorm:"type:text" json:"data"` // JSON payload
+	CreatedAt time.Time `json:"created_at"`
+}
+
+// Subscription is a saved search that the scheduler runs on a cron schedule,
+// saving posts it hasn't saved before into a Notion database
+type Subscription struct {
+	ID          uint               `gorm:"primaryKey" json:"id"`
+	UserID      uint               `gorm:"not null;index" json:"user_id"`
+	Name        string             `gorm:"not null" json:"name"`
+	Subreddits  []string           `gorm:"type:jsonb;not null;serializer:json" json:"subreddits"`
+	Keyword     string             `json:"keyword"`
+	Sort        string             `gorm:"not null" json:"sort"`
+	TimeRange   string             `json:"time_range"`
+	Filter      *reddit.PostFilter `gorm:"type:jsonb;serializer:json" json:"filter"`
+	Limit       int                `gorm:"not null" json:"limit"` // Posts fetched per run
+	WorkspaceID string             `gorm:"not null" json:"workspace_id"`
+	DatabaseID  string             `gorm:"not null" json:"database_id"`
+	Schedule    string             `gorm:"not null" json:"schedule"` // Cron expression, e.g. "0 8 * * *"
+	Timezone    string             `gorm:"not null;default:'UTC'" json:"timezone"`
+	Enabled     bool               `gorm:"not null;default:true" json:"enabled"`
+	NextRunAt   *time.Time         `gorm:"index" json:"next_run_at"` // nil while disabled
+	LastRunAt   *time.Time         `json:"last_run_at"`
+	CreatedAt   time.Time          `json:"created_at"`
+	UpdatedAt   time.Time          `json:"updated_at"`
+}
+
+// SubscriptionRun records one run of a subscription. New posts are saved by
+// a bulk save job, whose counts say how the saves went.
+type SubscriptionRun struct {
+	ID             uint       `gorm:"primaryKey" json:"id"`
+	SubscriptionID uint       `gorm:"not null;index" json:"subscription_id"`
+	Status         string     `gorm:"not null" json:"status"` // running, completed or failed
+	Fetched        int        `json:"fetched"`                // Posts matching the search
+	Queued         int        `json:"queued"`                 // Posts not saved before, handed to the job
+	JobID          *uint      `json:"job_id"`
+	Error          string     `gorm:"type:text" json:"error,omitempty"`
+	StartedAt      time.Time  `json:"started_at"`
+	FinishedAt     *time.Time `json:"finished_at"`
+
+	// Relations
+	Job *Job `gorm:"foreignKey:JobID" json:"job,omitempty"`
+}
@@ -4,14 +4,60 @@ import (
 	"context"
 	"fmt"
 	"log"
+	"net/http"
 	"strings"
 	"time"
 
+	"re2no/internal/config"
+	"re2no/reddit"
+
 	"github.com/jomei/notionapi"
 )
 
 type NotionClient struct {
-	client *notionapi.Client
+	client    *notionapi.Client
+	workspace string   // Keys the workspace's rate limiter and schema cache
+	Timeouts  Timeouts // Zero fields default to DefaultTimeouts
+}
+
+// Timeouts bounds each kind of Notion operation, on top of any deadline
+// already on the caller's context
+type Timeouts struct {
+	Read  time.Duration // Searching databases and loading schemas
+	Write time.Duration // Creating pages and databases, archiving pages
+}
+
+// DefaultTimeouts apply to clients that don't set their own; Init overrides
+// them from NOTION_READ_TIMEOUT and NOTION_WRITE_TIMEOUT
+var DefaultTimeouts = Timeouts{
+	Read:  15 * time.Second,
+	Write: 30 * time.Second,
+}
+
+// Init reads the Notion timeouts and schema cache TTL (Go durations such as
+// "30s") from the environment
+func Init() {
+	DefaultTimeouts.Read = config.Duration("NOTION_READ_TIMEOUT", DefaultTimeouts.Read)
+	DefaultTimeouts.Write = config.Duration("NOTION_WRITE_TIMEOUT", DefaultTimeouts.Write)
+	DefaultSchemaTTL = config.Duration("NOTION_SCHEMA_TTL", DefaultSchemaTTL)
+}
+
+// readContext bounds a read operation by the client's read timeout
+func (nc *NotionClient) readContext(ctx context.Context) (context.Context, context.CancelFunc) {
+	timeout := nc.Timeouts.Read
+	if timeout <= 0 {
+		timeout = DefaultTimeouts.Read
+	}
+	return context.WithTimeout(ctx, timeout)
+}
+
+// writeContext bounds a write operation by the client's write timeout
+func (nc *NotionClient) writeContext(ctx context.Context) (context.Context, context.CancelFunc) {
+	timeout := nc.Timeouts.Write
+	if timeout <= 0 {
+		timeout = DefaultTimeouts.Write
+	}
+	return context.WithTimeout(ctx, timeout)
 }
 
 type SavePostRequest struct {
@@ -23,6 +69,20 @@ type SavePostRequest struct {
 	URL        string `json:"url" binding:"required"`
 	RedditID   string `json:"reddit_id" binding:"required"`
 	DatabaseID string `json:"database_id" binding:"required"`
+
+	// IncludeComments is the number of top-level comments to append to the page
+	IncludeComments int `json:"include_comments"`
+
+	// Comments is filled in by the handler when IncludeComments is set
+	Comments []reddit.Comment `json:"-"`
+
+	// Post is the listing entry, when the handler has it; its media is
+	// embedded as native blocks and its preview becomes the page cover
+	Post *reddit.RedditPost `json:"-"`
+
+	// Mapping is the database's stored property mapping, filled in by the
+	// handler; without one it is suggested from the schema
+	Mapping PropertyMapping `json:"-"`
 }
 
 type SavePostResponse struct {
@@ -30,151 +90,175 @@ type SavePostResponse struct {
 	NotionPageURL string `json:"notion_page_url"`
 }
 
-// NewNotionClient creates a new Notion API client with the given access token
+// maxChildrenPerRequest is the most blocks Notion accepts in a single
+// children array, top-level or nested
+const maxChildrenPerRequest = 100
+
+// maxBlocksPerRequest is the most blocks, nested ones included, Notion
+// accepts in a single Page.Create or Block.AppendChildren call
+const maxBlocksPerRequest = 1000
+
+// NewNotionClient creates a new Notion API client with the given access token.
+// Requests are paced against the workspace's rate limit and retried on
+// transient failures.
 func NewNotionClient(accessToken string) *NotionClient {
+	workspace := workspaceKey(accessToken)
+	httpClient := &http.Client{Transport: newTransport(workspace)}
 	return &NotionClient{
-		client: notionapi.NewClient(notionapi.Token(accessToken)),
+		// Retries are left to the transport; notionapi's own 429 retry would
+		// repeat every attempt the transport already makes
+		client: notionapi.NewClient(notionapi.Token(accessToken),
+			notionapi.WithHTTPClient(httpClient),
+			notionapi.WithRetry(1), // Give up on the first 429 the transport lets through
+		),
+		workspace: workspace,
 	}
 }
 
 // SaveRedditPost saves a Reddit post to a Notion database (flexible properties)
-func (nc *NotionClient) SaveRedditPost(req SavePostRequest) (*SavePostResponse, error) {
+func (nc *NotionClient) SaveRedditPost(ctx context.Context, req SavePostRequest) (*SavePostResponse, error) {
 	log.Printf("[Notion] Saving Reddit post to Notion database: %s", req.DatabaseID)
 
 	// Parse database ID
 	dbID := notionapi.DatabaseID(req.DatabaseID)
 
-	// First, retrieve the database to see what properties it has
-	ctx := context.Background()
-	database, err := nc.client.Database.Get(ctx, dbID)
-	if err != nil {
-		log.Printf("[Notion] Error fetching database schema: %v", err)
-		return nil, fmt.Errorf("failed to fetch database: %w", err)
+	// Fill properties from the stored mapping, checked against the schema
+	// since the database may have changed since it was saved
+	mapping := req.Mapping
+	var schema notionapi.PropertyConfigs
+	var err error
+	if mapping == nil {
+		if schema, err = nc.GetDatabaseSchema(ctx, req.DatabaseID); err != nil {
+			return nil, err
+		}
+		log.Printf("[Notion] No property mapping stored, suggesting one from the schema")
+		mapping = SuggestMapping(schema)
+	} else if schema, err = nc.ValidateDatabaseMapping(ctx, req.DatabaseID, mapping); err != nil {
+		return nil, err
 	}
 
-	log.Printf("[Notion] Database schema loaded, building properties dynamically")
-
-	// Build properties dynamically based on what exists in the database
-	properties := nc.buildPropertiesFromSchema(database.Properties, req)
+	properties, err := buildProperties(schema, mapping, req, time.Now())
+	if err != nil {
+		return nil, err
+	}
+	log.Printf("[Notion] Built %d properties for page creation", len(properties))
 
 	// Create content blocks
-	children := nc.createContentBlocks(req.Content, req.URL)
+	children := nc.createContentBlocks(req.Content, req.URL, mediaBlocks(req.Post))
+	if len(req.Comments) > 0 {
+		children = append(children, nc.createCommentBlocks(req.Comments)...)
+	}
+
+	// Create the page with as many blocks as Notion takes in one request
+	first := children[:batchEnd(children)]
+	rest := children[len(first):]
 
-	// Create the page request
 	createPageReq := &notionapi.PageCreateRequest{
 		Parent: notionapi.Parent{
 			Type:       notionapi.ParentTypeDatabaseID,
 			DatabaseID: dbID,
 		},
 		Properties: properties,
-		Children:   children,
+		Children:   first,
+		Cover:      coverImage(req.Post),
 	}
 
 	// Create the page in Notion
-	page, err := nc.client.Page.Create(ctx, createPageReq)
+	writeCtx, cancelWrite := nc.writeContext(ctx)
+	page, err := nc.client.Page.Create(writeCtx, createPageReq)
+	cancelWrite()
 	if err != nil {
 		log.Printf("[Notion] Error creating page: %v", err)
+		if isSchemaError(err) {
+			// The cached schema is out of date; fetch it again next time
+			nc.InvalidateDatabaseSchema(req.DatabaseID)
+		}
 		return nil, fmt.Errorf("failed to create Notion page: %w", err)
 	}
 
 	log.Printf("[Notion] Successfully created page: %s", page.ID)
 
+	// Append the remaining blocks; a half-written page is worse than none, so
+	// archive it if any batch fails
+	if err := nc.appendBlocks(ctx, notionapi.BlockID(page.ID), rest); err != nil {
+		log.Printf("[Notion] Error appending blocks to page %s, archiving it: %v", page.ID, err)
+		if archiveErr := nc.DeletePage(context.WithoutCancel(ctx), string(page.ID)); archiveErr != nil {
+			log.Printf("[Notion] Failed to archive partially written page %s: %v", page.ID, archiveErr)
+		}
+		return nil, fmt.Errorf("failed to add content to Notion page: %w", err)
+	}
+
 	return &SavePostResponse{
 		NotionPageID:  string(page.ID),
 		NotionPageURL: page.URL,
 	}, nil
 }
 
-// buildPropertiesFromSchema builds properties dynamically based on the database schema
-func (nc *NotionClient) buildPropertiesFromSchema(schema notionapi.PropertyConfigs, req SavePostRequest) notionapi.Properties {
-	properties := notionapi.Properties{}
-	now := notionapi.Date(time.Now())
-
-	log.Printf("[Notion] Building properties from schema with %d properties", len(schema))
-
-	// Iterate through database properties and match with our data
-	for propName, propConfig := range schema {
-		propType := propConfig.GetType()
-		propNameLower := strings.ToLower(strings.ReplaceAll(propName, " ", "_"))
-
-		log.Printf("[Notion] Processing property: %s (type: %s)", propName, propType)
-
-		switch propType {
-		case notionapi.PropertyConfigTypeTitle:
-			// Always set the title property
-			properties[propName] = notionapi.TitleProperty{
-				Title: []notionapi.RichText{
-					{Text: &notionapi.Text{Content: req.Title}},
-				},
-			}
-			log.Printf("[Notion] Set title property: %s", propName)
-
-		case notionapi.PropertyConfigTypeRichText:
-			// Match rich text properties by name
-			var textValue string
-			switch {
-			case strings.Contains(propNameLower, "subreddit"):
-				textValue = req.Subreddit
-			case strings.Contains(propNameLower, "author"):
-				textValue = req.Author
-			case strings.Contains(propNameLower, "reddit") && strings.Contains(propNameLower, "id"):
-				textValue = req.RedditID
-			case strings.Contains(propNameLower, "content"):
-				textValue = req.Content
-			}
-
-			if textValue != "" {
-				properties[propName] = notionapi.RichTextProperty{
-					RichText: []notionapi.RichText{
-						{Text: &notionapi.Text{Content: textValue}},
-					},
-				}
-				log.Printf("[Notion] Set rich text property: %s = %s", propName, textValue[:min(50, len(textValue))])
-			}
-
-		case notionapi.PropertyConfigTypeNumber:
-			// Match number properties
-			if strings.Contains(propNameLower, "score") || strings.Contains(propNameLower, "upvote") {
-				properties[propName] = notionapi.NumberProperty{
-					Number: float64(req.Score),
-				}
-				log.Printf("[Notion] Set number property: %s = %d", propName, req.Score)
-			}
-
-		case notionapi.PropertyConfigTypeURL:
-			// Match URL properties
-			if strings.Contains(propNameLower, "url") || strings.Contains(propNameLower, "link") || strings.Contains(propNameLower, "reddit") {
-				properties[propName] = notionapi.URLProperty{
-					URL: req.URL,
-				}
-				log.Printf("[Notion] Set URL property: %s = %s", propName, req.URL)
-			}
+// appendBlocks adds blocks to the end of a page or block in batches Notion accepts
+func (nc *NotionClient) appendBlocks(ctx context.Context, blockID notionapi.BlockID, blocks []notionapi.Block) error {
+	for start := 0; start < len(blocks); {
+		end := start + batchEnd(blocks[start:])
+
+		writeCtx, cancel := nc.writeContext(ctx)
+		_, err := nc.client.Block.AppendChildren(writeCtx, blockID, &notionapi.AppendBlockChildrenRequest{
+			Children: blocks[start:end],
+		})
+		cancel()
+		if err != nil {
+			return err
+		}
+		log.Printf("[Notion] Appended %d of %d remaining blocks to %s", end, len(blocks), blockID)
+		start = end
+	}
+	return nil
+}
 
-		case notionapi.PropertyConfigTypeDate:
-			// Match date properties
-			if strings.Contains(propNameLower, "saved") || strings.Contains(propNameLower, "created") || strings.Contains(propNameLower, "date") {
-				properties[propName] = notionapi.DateProperty{
-					Date: &notionapi.DateObject{Start: &now},
-				}
-				log.Printf("[Notion] Set date property: %s", propName)
-			}
+// batchEnd returns how many blocks from the front of blocks fit in one
+// request: at most maxChildrenPerRequest of them, and at most
+// maxBlocksPerRequest counting their nested children. A lone block is always
+// taken so batching makes progress.
+func batchEnd(blocks []notionapi.Block) int {
+	total := 0
+	for i, block := range blocks {
+		total += countBlocks(block)
+		if i == maxChildrenPerRequest || (i > 0 && total > maxBlocksPerRequest) {
+			return i
 		}
 	}
+	return len(blocks)
+}
 
-	log.Printf("[Notion] Built %d properties for page creation", len(properties))
-	return properties
+// countBlocks counts a block and every block nested under it
+func countBlocks(block notionapi.Block) int {
+	count := 1
+	for _, child := range blockChildren(block) {
+		count += countBlocks(child)
+	}
+	return count
 }
 
-// min returns the minimum of two integers
-func min(a, b int) int {
-	if a < b {
-		return a
+// blockChildren returns the nested children of the block types this package creates
+func blockChildren(block notionapi.Block) []notionapi.Block {
+	switch b := block.(type) {
+	case notionapi.ParagraphBlock:
+		return b.Paragraph.Children
+	case notionapi.BulletedListItemBlock:
+		return b.BulletedListItem.Children
+	case notionapi.NumberedListItemBlock:
+		return b.NumberedListItem.Children
+	case notionapi.QuoteBlock:
+		return b.Quote.Children
+	case notionapi.ToggleBlock:
+		return b.Toggle.Children
+	case notionapi.TableBlock:
+		return b.Table.Children
 	}
-	return b
+	return nil
 }
 
-// createContentBlocks creates Notion blocks from the Reddit post content
-func (nc *NotionClient) createContentBlocks(content, url string) []notionapi.Block {
+// createContentBlocks creates Notion blocks from the Reddit post content.
+// Media blocks, when given, replace the plain link to the post's media.
+func (nc *NotionClient) createContentBlocks(content, url string, media []notionapi.Block) []notionapi.Block {
 	blocks := []notionapi.Block{}
 
 	// Add the Reddit link as a bookmark
@@ -196,68 +280,53 @@ func (nc *NotionClient) createContentBlocks(content, url string) []notionapi.Blo
 		},
 	})
 
-	// If there's content, add it as paragraphs
-	if content != "" {
-		// Check if content is a URL (image/video link)
-		if strings.HasPrefix(content, "http://") || strings.HasPrefix(content, "https://") {
-			// Create a clickable link block for media URLs
-			blocks = append(blocks, notionapi.ParagraphBlock{
-				BasicBlock: notionapi.BasicBlock{
-					Object: notionapi.ObjectTypeBlock,
-					Type:   notionapi.BlockTypeParagraph,
-				},
-				Paragraph: notionapi.Paragraph{
-					RichText: []notionapi.RichText{
-						{
-							Type: notionapi.ObjectTypeText,
-							Text: &notionapi.Text{Content: "Media Link: "},
+	blocks = append(blocks, media...)
+
+	switch {
+	case len(media) > 0 && (content == "" || isURL(content)):
+		// The media blocks already show what the post links to
+	case content == "":
+		// Add a placeholder if there's no content
+		blocks = append(blocks, nc.createParagraphBlock("No content available for this post."))
+	case isURL(content):
+		// Create a clickable link block for media URLs
+		blocks = append(blocks, notionapi.ParagraphBlock{
+			BasicBlock: notionapi.BasicBlock{
+				Object: notionapi.ObjectTypeBlock,
+				Type:   notionapi.BlockTypeParagraph,
+			},
+			Paragraph: notionapi.Paragraph{
+				RichText: []notionapi.RichText{
+					{
+						Type: notionapi.ObjectTypeText,
+						Text: &notionapi.Text{Content: "Media Link: "},
+					},
+					{
+						Type: notionapi.ObjectTypeText,
+						Text: &notionapi.Text{
+							Content: content,
+							Link:    &notionapi.Link{Url: content},
 						},
-						{
-							Type: notionapi.ObjectTypeText,
-							Text: &notionapi.Text{
-								Content: content,
-								Link:    &notionapi.Link{Url: content},
-							},
-							Annotations: &notionapi.Annotations{
-								Color: notionapi.ColorBlue,
-							},
+						Annotations: &notionapi.Annotations{
+							Color: notionapi.ColorBlue,
 						},
 					},
 				},
-			})
-		} else {
-			// Split content into paragraphs (by double newline or single newline)
-			paragraphs := strings.Split(content, "\n\n")
-			if len(paragraphs) == 1 {
-				paragraphs = strings.Split(content, "\n")
-			}
-
-			for _, para := range paragraphs {
-				para = strings.TrimSpace(para)
-				if para == "" {
-					continue
-				}
-
-				// Notion has a 2000 character limit per rich text block
-				// Split long paragraphs if needed
-				if len(para) > 2000 {
-					chunks := splitTextIntoChunks(para, 2000)
-					for _, chunk := range chunks {
-						blocks = append(blocks, nc.createParagraphBlock(chunk))
-					}
-				} else {
-					blocks = append(blocks, nc.createParagraphBlock(para))
-				}
-			}
-		}
-	} else {
-		// Add a placeholder if there's no content
-		blocks = append(blocks, nc.createParagraphBlock("No content available for this post."))
+			},
+		})
+	default:
+		// Convert the selftext markdown into native Notion blocks
+		blocks = append(blocks, MarkdownToBlocks(content)...)
 	}
 
 	return blocks
 }
 
+// isURL reports whether content is a bare link rather than post text
+func isURL(content string) bool {
+	return strings.HasPrefix(content, "http://") || strings.HasPrefix(content, "https://")
+}
+
 // createParagraphBlock creates a paragraph block from text
 func (nc *NotionClient) createParagraphBlock(text string) notionapi.ParagraphBlock {
 	return notionapi.ParagraphBlock{
@@ -277,31 +346,112 @@ func (nc *NotionClient) createParagraphBlock(text string) notionapi.ParagraphBlo
 	}
 }
 
-// splitTextIntoChunks splits text into chunks of specified size
-func splitTextIntoChunks(text string, chunkSize int) []string {
-	var chunks []string
-	for len(text) > 0 {
-		if len(text) <= chunkSize {
-			chunks = append(chunks, text)
-			break
+// createCommentBlocks renders a comment tree as nested toggle blocks.
+// Notion only accepts two levels of nesting per request, so replies are
+// rendered one level deep and anything below is summarised.
+func (nc *NotionClient) createCommentBlocks(comments []reddit.Comment) []notionapi.Block {
+	blocks := []notionapi.Block{
+		notionapi.DividerBlock{
+			BasicBlock: notionapi.BasicBlock{
+				Object: notionapi.ObjectTypeBlock,
+				Type:   notionapi.BlockTypeDivider,
+			},
+		},
+		notionapi.Heading2Block{
+			BasicBlock: notionapi.BasicBlock{
+				Object: notionapi.ObjectTypeBlock,
+				Type:   notionapi.BlockTypeHeading2,
+			},
+			Heading2: notionapi.Heading{
+				RichText: []notionapi.RichText{
+					{Text: &notionapi.Text{Content: "Top Comments"}},
+				},
+			},
+		},
+	}
+
+	// Each toggle's children must fit in one array of at most
+	// maxChildrenPerRequest blocks, and the whole toggle in one request of
+	// maxBlocksPerRequest: long bodies are cut short and replies that don't
+	// fit are counted with the ones Reddit didn't send
+	for _, comment := range comments {
+		children := nc.truncateBlocks(nc.createCommentBodyBlocks(comment.Body, maxNestingDepth-1), maxCommentBodyBlocks)
+
+		// The toggle itself and a hidden-replies note are always added
+		size := 2
+		for _, child := range children {
+			size += countBlocks(child)
 		}
-		// Try to split at a word boundary
-		splitIndex := chunkSize
-		for splitIndex > 0 && text[splitIndex] != ' ' && text[splitIndex] != '\n' {
-			splitIndex--
+
+		hidden := comment.MoreReplies
+		for i, reply := range comment.Replies {
+			replyChildren := nc.truncateBlocks(nc.createCommentBodyBlocks(reply.Body, 0), maxChildrenPerRequest-1)
+			if replyHidden := len(reply.Replies) + reply.MoreReplies; replyHidden > 0 {
+				replyChildren = append(replyChildren, nc.createParagraphBlock(fmt.Sprintf("(%d more replies on Reddit)", replyHidden)))
+			}
+			toggle := nc.createCommentToggle(reply, replyChildren)
+
+			replySize := countBlocks(toggle)
+			if len(children)+1 >= maxChildrenPerRequest || size+replySize > maxBlocksPerRequest {
+				hidden += len(comment.Replies) - i
+				break
+			}
+			size += replySize
+			children = append(children, toggle)
 		}
-		if splitIndex == 0 {
-			splitIndex = chunkSize
+		if hidden > 0 {
+			children = append(children, nc.createParagraphBlock(fmt.Sprintf("(%d more replies on Reddit)", hidden)))
 		}
-		chunks = append(chunks, text[:splitIndex])
-		text = text[splitIndex:]
-		text = strings.TrimSpace(text)
+		blocks = append(blocks, nc.createCommentToggle(comment, children))
 	}
-	return chunks
+
+	return blocks
+}
+
+// maxCommentBodyBlocks is how many blocks of a comment's own text its toggle
+// shows, leaving the rest of the toggle for replies
+const maxCommentBodyBlocks = maxChildrenPerRequest / 2
+
+// truncateBlocks cuts a comment body down to at most limit blocks, ending
+// with a note when anything was left out
+func (nc *NotionClient) truncateBlocks(blocks []notionapi.Block, limit int) []notionapi.Block {
+	if len(blocks) <= limit {
+		return blocks
+	}
+	truncated := append([]notionapi.Block{}, blocks[:limit-1]...)
+	return append(truncated, nc.createParagraphBlock("… (continued on Reddit)"))
+}
+
+// createCommentToggle creates a toggle block headed by the comment's author and score
+func (nc *NotionClient) createCommentToggle(comment reddit.Comment, children []notionapi.Block) notionapi.ToggleBlock {
+	return notionapi.ToggleBlock{
+		BasicBlock: notionapi.BasicBlock{
+			Object: notionapi.ObjectTypeBlock,
+			Type:   notionapi.BlockTypeToggle,
+		},
+		Toggle: notionapi.Toggle{
+			RichText: []notionapi.RichText{
+				{
+					Text:        &notionapi.Text{Content: "u/" + comment.Author},
+					Annotations: &notionapi.Annotations{Bold: true},
+				},
+				{
+					Text:        &notionapi.Text{Content: fmt.Sprintf(" · %d points", comment.Score)},
+					Annotations: &notionapi.Annotations{Color: notionapi.ColorGray},
+				},
+			},
+			Children: children,
+		},
+	}
+}
+
+// createCommentBodyBlocks converts a comment body into blocks nested at most depth levels deep
+func (nc *NotionClient) createCommentBodyBlocks(body string, depth int) []notionapi.Block {
+	return convertMarkdown(body, depth)
 }
 
 // GetDatabases retrieves all databases accessible to the integration
-func (nc *NotionClient) GetDatabases() ([]notionapi.Database, error) {
+func (nc *NotionClient) GetDatabases(ctx context.Context) ([]notionapi.Database, error) {
 	log.Printf("[Notion] Fetching accessible databases")
 
 	// Search for databases
@@ -312,7 +462,8 @@ func (nc *NotionClient) GetDatabases() ([]notionapi.Database, error) {
 		},
 	}
 
-	ctx := context.Background()
+	ctx, cancel := nc.readContext(ctx)
+	defer cancel()
 	searchResp, err := nc.client.Search.Do(ctx, searchReq)
 	if err != nil {
 		log.Printf("[Notion] Error searching databases: %v", err)
@@ -324,6 +475,7 @@ func (nc *NotionClient) GetDatabases() ([]notionapi.Database, error) {
 		if result.GetObject() == "database" {
 			// Type assert to database
 			if db, ok := result.(*notionapi.Database); ok {
+				schemas.observe(nc.schemaKey(string(db.ID)), db.LastEditedTime)
 				databases = append(databases, *db)
 			}
 		}
@@ -334,10 +486,11 @@ func (nc *NotionClient) GetDatabases() ([]notionapi.Database, error) {
 }
 
 // CreateRedditPostsDatabase creates a new database with the required schema for Reddit posts
-func (nc *NotionClient) CreateRedditPostsDatabase(parentPageID string) (*notionapi.Database, error) {
+func (nc *NotionClient) CreateRedditPostsDatabase(ctx context.Context, parentPageID string) (*notionapi.Database, error) {
 	log.Printf("[Notion] Creating Reddit Posts database in page: %s", parentPageID)
 
-	ctx := context.Background()
+	ctx, cancel := nc.writeContext(ctx)
+	defer cancel()
 
 	createDBReq := &notionapi.DatabaseCreateRequest{
 		Parent: notionapi.Parent{
@@ -383,10 +536,11 @@ func (nc *NotionClient) CreateRedditPostsDatabase(parentPageID string) (*notiona
 }
 
 // DeletePage deletes (archives) a Notion page by its ID
-func (nc *NotionClient) DeletePage(pageID string) error {
+func (nc *NotionClient) DeletePage(ctx context.Context, pageID string) error {
 	log.Printf("[Notion] Deleting page: %s", pageID)
 
-	ctx := context.Background()
+	ctx, cancel := nc.writeContext(ctx)
+	defer cancel()
 
 	// Archive the page (Notion API doesn't have direct delete, uses archive)
 	_, err := nc.client.Block.Delete(ctx, notionapi.BlockID(pageID))
@@ -0,0 +1,77 @@
+package notion
+
+import (
+	"strings"
+	"testing"
+
+	"github.com/jomei/notionapi"
+
+	"re2no/reddit"
+)
+
+func TestCreateCommentBlocksFitOneRequest(t *testing.T) {
+	nc := &NotionClient{}
+
+	// Every reply is a long list, so the replies run out of room in the
+	// request well before they fill the toggle's children array
+	longBody := strings.Repeat("- point\n\n", 150)
+	replies := make([]reddit.Comment, 60)
+	for i := range replies {
+		replies[i] = reddit.Comment{Author: "replier", Body: longBody}
+	}
+	comments := []reddit.Comment{{Author: "op", Body: longBody, Replies: replies}}
+
+	blocks := nc.createCommentBlocks(comments)
+	toggle := blocks[len(blocks)-1].(notionapi.ToggleBlock)
+
+	if size := countBlocks(toggle); size > maxBlocksPerRequest {
+		t.Errorf("comment toggle holds %d blocks, want at most %d", size, maxBlocksPerRequest)
+	}
+	children := toggle.Toggle.Children
+	if len(children) > maxChildrenPerRequest {
+		t.Errorf("comment toggle has %d children, want at most %d", len(children), maxChildrenPerRequest)
+	}
+	note := render(children[len(children)-1:])[0]
+	if !strings.HasPrefix(note, "p: (") || !strings.HasSuffix(note, "more replies on Reddit)") {
+		t.Errorf("last child = %q, want the hidden replies note", note)
+	}
+}
+
+func TestBatchEnd(t *testing.T) {
+	nc := &NotionClient{}
+	paragraph := nc.createParagraphBlock("text")
+	toggle := func(children int) notionapi.Block {
+		blocks := make([]notionapi.Block, children)
+		for i := range blocks {
+			blocks[i] = paragraph
+		}
+		return nc.createCommentToggle(reddit.Comment{Author: "a"}, blocks)
+	}
+	repeat := func(block notionapi.Block, n int) []notionapi.Block {
+		blocks := make([]notionapi.Block, n)
+		for i := range blocks {
+			blocks[i] = block
+		}
+		return blocks
+	}
+
+	tests := []struct {
+		name   string
+		blocks []notionapi.Block
+		want   int
+	}{
+		{"empty", nil, 0},
+		{"few flat blocks", repeat(paragraph, 10), 10},
+		{"too many top-level blocks", repeat(paragraph, 150), maxChildrenPerRequest},
+		{"too many nested blocks", repeat(toggle(99), 20), 10},
+		{"oversized block goes alone", repeat(toggle(1500), 2), 1},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if got := batchEnd(tt.blocks); got != tt.want {
+				t.Errorf("batchEnd() = %d, want %d", got, tt.want)
+			}
+		})
+	}
+}
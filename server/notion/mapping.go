@@ -0,0 +1,397 @@
+package notion
+
+import (
+	"fmt"
+	"sort"
+	"strconv"
+	"strings"
+	"time"
+
+	"re2no/reddit"
+
+	"github.com/jomei/notionapi"
+)
+
+// Reddit fields a database property can be filled from
+const (
+	FieldTitle       = "title"
+	FieldSubreddit   = "subreddit"
+	FieldAuthor      = "author"
+	FieldContent     = "content"
+	FieldScore       = "score"
+	FieldURL         = "url"      // Permalink to the post on Reddit
+	FieldLinkURL     = "link_url" // What a link post points to
+	FieldRedditID    = "reddit_id"
+	FieldSavedAt     = "saved_at"
+	FieldCreatedAt   = "created_at"
+	FieldNumComments = "num_comments"
+	FieldUpvoteRatio = "upvote_ratio"
+	FieldFlair       = "flair"
+	FieldDomain      = "domain"
+	FieldKind        = "kind"
+	FieldNSFW        = "nsfw"
+)
+
+// Kinds of value a field produces, which decide the property types it fits
+const (
+	valueText   = "text"
+	valueNumber = "number"
+	valueURL    = "url"
+	valueDate   = "date"
+	valueBool   = "bool"
+)
+
+// fieldKinds lists every mappable field with the kind of value it produces
+var fieldKinds = map[string]string{
+	FieldTitle:       valueText,
+	FieldSubreddit:   valueText,
+	FieldAuthor:      valueText,
+	FieldContent:     valueText,
+	FieldScore:       valueNumber,
+	FieldURL:         valueURL,
+	FieldLinkURL:     valueURL,
+	FieldRedditID:    valueText,
+	FieldSavedAt:     valueDate,
+	FieldCreatedAt:   valueDate,
+	FieldNumComments: valueNumber,
+	FieldUpvoteRatio: valueNumber,
+	FieldFlair:       valueText,
+	FieldDomain:      valueText,
+	FieldKind:        valueText,
+	FieldNSFW:        valueBool,
+}
+
+// propertyAccepts lists the value kinds each supported property type can be
+// filled from; values are converted to the property's type when saving
+var propertyAccepts = map[notionapi.PropertyConfigType][]string{
+	notionapi.PropertyConfigTypeTitle:       {valueText, valueNumber, valueURL, valueDate, valueBool},
+	notionapi.PropertyConfigTypeRichText:    {valueText, valueNumber, valueURL, valueDate, valueBool},
+	notionapi.PropertyConfigTypeNumber:      {valueNumber},
+	notionapi.PropertyConfigTypeURL:         {valueURL, valueText},
+	notionapi.PropertyConfigTypeDate:        {valueDate},
+	notionapi.PropertyConfigTypeCheckbox:    {valueBool},
+	notionapi.PropertyConfigTypeSelect:      {valueText, valueNumber, valueBool},
+	notionapi.PropertyConfigTypeMultiSelect: {valueText},
+}
+
+// PropertySource says what fills a database property: a Reddit field, or a
+// constant used for every post when Field is empty
+type PropertySource struct {
+	Field    string `json:"field,omitempty"`
+	Constant string `json:"constant,omitempty"`
+}
+
+// PropertyMapping maps database property names to their sources. Properties
+// that aren't mapped are left empty.
+type PropertyMapping map[string]PropertySource
+
+// MappingError lists the ways a mapping doesn't fit a database's schema
+type MappingError struct {
+	Problems []string `json:"problems"`
+}
+
+func (e *MappingError) Error() string {
+	return "property mapping doesn't match the database: " + strings.Join(e.Problems, "; ")
+}
+
+// MappableFields returns the Reddit fields a property can be mapped to, sorted
+func MappableFields() []string {
+	fields := make([]string, 0, len(fieldKinds))
+	for field := range fieldKinds {
+		fields = append(fields, field)
+	}
+	sort.Strings(fields)
+	return fields
+}
+
+// ValidateMapping checks a mapping against a database's live schema: every
+// property must exist and have a type the field or constant converts to.
+// It returns a *MappingError describing every problem, or nil.
+func ValidateMapping(schema notionapi.PropertyConfigs, mapping PropertyMapping) error {
+	var problems []string
+	for propName, source := range mapping {
+		config, ok := schema[propName]
+		if !ok {
+			problems = append(problems, fmt.Sprintf("property %q doesn't exist", propName))
+			continue
+		}
+		propType := config.GetType()
+		accepts, supported := propertyAccepts[propType]
+		if !supported {
+			problems = append(problems, fmt.Sprintf("property %q has unsupported type %s", propName, propType))
+			continue
+		}
+
+		if source.Field == "" {
+			if _, err := convertValue(propType, source.Constant); err != nil {
+				problems = append(problems, fmt.Sprintf("property %q: %v", propName, err))
+			}
+			continue
+		}
+
+		kind, known := fieldKinds[source.Field]
+		if !known {
+			problems = append(problems, fmt.Sprintf("property %q: unknown field %q", propName, source.Field))
+			continue
+		}
+		if !containsKind(accepts, kind) {
+			problems = append(problems, fmt.Sprintf("property %q (%s) can't hold %s", propName, propType, source.Field))
+		}
+	}
+
+	if len(problems) > 0 {
+		sort.Strings(problems)
+		return &MappingError{Problems: problems}
+	}
+	return nil
+}
+
+// SuggestMapping guesses a mapping from property names and types
+func SuggestMapping(schema notionapi.PropertyConfigs) PropertyMapping {
+	mapping := PropertyMapping{}
+
+	for propName, propConfig := range schema {
+		propNameLower := strings.ToLower(strings.ReplaceAll(propName, " ", "_"))
+
+		var field string
+		switch propConfig.GetType() {
+		case notionapi.PropertyConfigTypeTitle:
+			field = FieldTitle
+
+		case notionapi.PropertyConfigTypeRichText:
+			switch {
+			case strings.Contains(propNameLower, "subreddit"):
+				field = FieldSubreddit
+			case strings.Contains(propNameLower, "author"):
+				field = FieldAuthor
+			case strings.Contains(propNameLower, "reddit") && strings.Contains(propNameLower, "id"):
+				field = FieldRedditID
+			case strings.Contains(propNameLower, "content"):
+				field = FieldContent
+			}
+
+		case notionapi.PropertyConfigTypeNumber:
+			if strings.Contains(propNameLower, "score") || strings.Contains(propNameLower, "upvote") {
+				field = FieldScore
+			}
+
+		case notionapi.PropertyConfigTypeURL:
+			if strings.Contains(propNameLower, "url") || strings.Contains(propNameLower, "link") || strings.Contains(propNameLower, "reddit") {
+				field = FieldURL
+			}
+
+		case notionapi.PropertyConfigTypeDate:
+			if strings.Contains(propNameLower, "saved") || strings.Contains(propNameLower, "created") || strings.Contains(propNameLower, "date") {
+				field = FieldSavedAt
+			}
+		}
+
+		if field != "" {
+			mapping[propName] = PropertySource{Field: field}
+		}
+	}
+
+	return mapping
+}
+
+// buildProperties fills a page's properties from a validated mapping. Fields
+// the request has no value for (e.g. created_at without the listing data)
+// are left empty.
+func buildProperties(schema notionapi.PropertyConfigs, mapping PropertyMapping, req SavePostRequest, now time.Time) (notionapi.Properties, error) {
+	properties := notionapi.Properties{}
+
+	for propName, source := range mapping {
+		value := any(source.Constant)
+		if source.Field != "" {
+			var ok bool
+			if value, ok = fieldValue(source.Field, req, now); !ok {
+				continue
+			}
+		}
+
+		property, err := convertValue(schema[propName].GetType(), value)
+		if err != nil {
+			return nil, fmt.Errorf("property %q: %w", propName, err)
+		}
+		properties[propName] = property
+	}
+
+	return properties, nil
+}
+
+// fieldValue returns a field's value for a post: a string, float64,
+// time.Time or bool. ok is false when the request doesn't carry it.
+func fieldValue(field string, req SavePostRequest, now time.Time) (value any, ok bool) {
+	post := req.Post
+	switch field {
+	case FieldTitle:
+		return req.Title, true
+	case FieldSubreddit:
+		return req.Subreddit, req.Subreddit != ""
+	case FieldAuthor:
+		return req.Author, req.Author != ""
+	case FieldContent:
+		return req.Content, req.Content != ""
+	case FieldScore:
+		return float64(req.Score), true
+	case FieldURL:
+		return req.URL, req.URL != ""
+	case FieldRedditID:
+		return req.RedditID, true
+	case FieldSavedAt:
+		return now, true
+	}
+
+	// The rest come from the listing data
+	if post == nil {
+		return nil, false
+	}
+	switch field {
+	case FieldLinkURL:
+		return post.URL, post.URL != ""
+	case FieldCreatedAt:
+		return post.CreatedUTC.Time, !post.CreatedUTC.IsZero()
+	case FieldNumComments:
+		return float64(post.NumComments), true
+	case FieldUpvoteRatio:
+		return post.UpvoteRatio, true
+	case FieldFlair:
+		return post.LinkFlair, post.LinkFlair != ""
+	case FieldDomain:
+		return post.Domain, post.Domain != ""
+	case FieldKind:
+		return reddit.PostKind(*post), true
+	case FieldNSFW:
+		return post.Over18, true
+	}
+	return nil, false
+}
+
+// convertValue converts a field value or constant to a property of propType
+func convertValue(propType notionapi.PropertyConfigType, value any) (notionapi.Property, error) {
+	switch propType {
+	case notionapi.PropertyConfigTypeTitle:
+		return notionapi.TitleProperty{Title: textValue(formatValue(value))}, nil
+
+	case notionapi.PropertyConfigTypeRichText:
+		return notionapi.RichTextProperty{RichText: textValue(formatValue(value))}, nil
+
+	case notionapi.PropertyConfigTypeNumber:
+		switch v := value.(type) {
+		case float64:
+			return notionapi.NumberProperty{Number: v}, nil
+		case string:
+			number, err := strconv.ParseFloat(strings.TrimSpace(v), 64)
+			if err != nil {
+				return nil, fmt.Errorf("%q is not a number", v)
+			}
+			return notionapi.NumberProperty{Number: number}, nil
+		}
+
+	case notionapi.PropertyConfigTypeURL:
+		if v, ok := value.(string); ok {
+			return notionapi.URLProperty{URL: v}, nil
+		}
+
+	case notionapi.PropertyConfigTypeDate:
+		switch v := value.(type) {
+		case time.Time:
+			date := notionapi.Date(v)
+			return notionapi.DateProperty{Date: &notionapi.DateObject{Start: &date}}, nil
+		case string:
+			parsed, err := parseDate(v)
+			if err != nil {
+				return nil, err
+			}
+			date := notionapi.Date(parsed)
+			return notionapi.DateProperty{Date: &notionapi.DateObject{Start: &date}}, nil
+		}
+
+	case notionapi.PropertyConfigTypeCheckbox:
+		switch v := value.(type) {
+		case bool:
+			return notionapi.CheckboxProperty{Checkbox: v}, nil
+		case string:
+			checked, err := strconv.ParseBool(strings.TrimSpace(v))
+			if err != nil {
+				return nil, fmt.Errorf("%q is not true or false", v)
+			}
+			return notionapi.CheckboxProperty{Checkbox: checked}, nil
+		}
+
+	case notionapi.PropertyConfigTypeSelect:
+		return notionapi.SelectProperty{Select: notionapi.Option{Name: selectName(formatValue(value))}}, nil
+
+	case notionapi.PropertyConfigTypeMultiSelect:
+		options := []notionapi.Option{}
+		for _, name := range strings.Split(formatValue(value), ",") {
+			if name = strings.TrimSpace(name); name != "" {
+				options = append(options, notionapi.Option{Name: selectName(name)})
+			}
+		}
+		return notionapi.MultiSelectProperty{MultiSelect: options}, nil
+
+	default:
+		return nil, fmt.Errorf("unsupported property type %s", propType)
+	}
+
+	return nil, fmt.Errorf("can't convert %v to %s", value, propType)
+}
+
+// formatValue renders a value as text
+func formatValue(value any) string {
+	switch v := value.(type) {
+	case string:
+		return v
+	case float64:
+		return strconv.FormatFloat(v, 'f', -1, 64)
+	case bool:
+		return strconv.FormatBool(v)
+	case time.Time:
+		return v.Format(time.RFC3339)
+	}
+	return fmt.Sprint(value)
+}
+
+// textValue converts text to rich text within Notion's length limits,
+// truncating what doesn't fit
+func textValue(text string) []notionapi.RichText {
+	richText := []notionapi.RichText{}
+	for _, chunk := range splitRunes(text, maxRichTextLength) {
+		if len(richText) == maxRichTextItems {
+			break
+		}
+		richText = append(richText, notionapi.RichText{Text: &notionapi.Text{Content: chunk}})
+	}
+	return richText
+}
+
+// selectName makes a value usable as a select option: Notion rejects commas
+// and limits names to 100 characters
+func selectName(name string) string {
+	name = strings.ReplaceAll(name, ",", " ")
+	if runes := []rune(name); len(runes) > 100 {
+		name = string(runes[:100])
+	}
+	return name
+}
+
+// parseDate accepts RFC 3339 timestamps and plain dates
+func parseDate(value string) (time.Time, error) {
+	value = strings.TrimSpace(value)
+	for _, layout := range []string{time.RFC3339, "2006-01-02"} {
+		if parsed, err := time.Parse(layout, value); err == nil {
+			return parsed, nil
+		}
+	}
+	return time.Time{}, fmt.Errorf("%q is not a date (use YYYY-MM-DD or RFC 3339)", value)
+}
+
+func containsKind(kinds []string, kind string) bool {
+	for _, k := range kinds {
+		if k == kind {
+			return true
+		}
+	}
+	return false
+}
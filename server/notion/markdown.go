@@ -0,0 +1,898 @@
+package notion
+
+import (
+	"html"
+	"regexp"
+	"strings"
+	"unicode"
+	"unicode/utf8"
+
+	"github.com/jomei/notionapi"
+)
+
+// Notion API limits that apply to converted content
+const (
+	maxRichTextLength = 2000 // characters per rich text object
+	maxRichTextItems  = 100  // rich text objects per block
+	maxNestingDepth   = 2    // levels of children allowed in a single request
+)
+
+var (
+	headingPattern     = regexp.MustCompile(`^(#{1,6})\s*(.*?)\s*#*\s*$`)
+	hrPattern          = regexp.MustCompile(`^\s*(?:(?:-\s*){3,}|(?:\*\s*){3,}|(?:_\s*){3,})$`)
+	listItemPattern    = regexp.MustCompile(`^(\s*)([-*+]|\d{1,9}[.)])\s+(.*)$`)
+	tableDividerRegexp = regexp.MustCompile(`^\s*\|?\s*:?-+:?\s*(\|\s*:?-+:?\s*)*\|?\s*$`)
+	setextH1Pattern    = regexp.MustCompile(`^\s*=+\s*$`)
+	setextH2Pattern    = regexp.MustCompile(`^\s*-+\s*$`)
+	subredditPattern   = regexp.MustCompile(`^/?([ru])/([A-Za-z0-9_-]{2,21})`)
+)
+
+// codeLanguages maps common fence info strings to Notion's code block languages
+var codeLanguages = map[string]string{
+	"bash": "bash", "sh": "shell", "shell": "shell", "zsh": "shell",
+	"c": "c", "cpp": "c++", "c++": "c++", "cs": "c#", "csharp": "c#",
+	"css": "css", "diff": "diff", "docker": "docker", "dockerfile": "docker",
+	"go": "go", "golang": "go", "graphql": "graphql", "html": "html",
+	"java": "java", "javascript": "javascript", "js": "javascript",
+	"json": "json", "kotlin": "kotlin", "lua": "lua", "makefile": "makefile",
+	"markdown": "markdown", "md": "markdown", "php": "php", "powershell": "powershell",
+	"py": "python", "python": "python", "r": "r", "rb": "ruby", "ruby": "ruby",
+	"rs": "rust", "rust": "rust", "scala": "scala", "sql": "sql", "swift": "swift",
+	"ts": "typescript", "typescript": "typescript", "xml": "xml",
+	"yaml": "yaml", "yml": "yaml",
+}
+
+// MarkdownToBlocks converts Reddit-flavoured markdown into Notion blocks
+func MarkdownToBlocks(markdown string) []notionapi.Block {
+	return convertMarkdown(markdown, maxNestingDepth)
+}
+
+// convertMarkdown converts markdown into blocks that may nest at most depth levels deep
+func convertMarkdown(markdown string, depth int) []notionapi.Block {
+	// Reddit escapes &, < and > in selftext
+	markdown = html.UnescapeString(markdown)
+	markdown = strings.ReplaceAll(markdown, "\r\n", "\n")
+
+	p := &markdownParser{
+		lines: strings.Split(markdown, "\n"),
+		depth: depth,
+	}
+	return p.parse()
+}
+
+// markdownParser turns markdown lines into Notion blocks
+type markdownParser struct {
+	lines     []string
+	pos       int
+	depth     int
+	paragraph []string
+	blocks    []notionapi.Block
+}
+
+func (p *markdownParser) parse() []notionapi.Block {
+	for p.pos < len(p.lines) {
+		line := p.lines[p.pos]
+		trimmed := strings.TrimSpace(line)
+
+		switch {
+		case trimmed == "":
+			p.flushParagraph()
+			p.pos++
+
+		case strings.HasPrefix(trimmed, "```") || strings.HasPrefix(trimmed, "~~~"):
+			p.flushParagraph()
+			p.parseFencedCode(trimmed[:3], strings.TrimSpace(trimmed[3:]))
+
+		case len(p.paragraph) == 0 && isIndentedCode(line):
+			p.parseIndentedCode()
+
+		case len(p.paragraph) > 0 && setextH1Pattern.MatchString(line):
+			p.flushParagraphAsHeading(1)
+			p.pos++
+
+		case len(p.paragraph) > 0 && setextH2Pattern.MatchString(line):
+			p.flushParagraphAsHeading(2)
+			p.pos++
+
+		case hrPattern.MatchString(line):
+			p.flushParagraph()
+			p.blocks = append(p.blocks, newDividerBlock())
+			p.pos++
+
+		case headingPattern.MatchString(trimmed):
+			p.flushParagraph()
+			match := headingPattern.FindStringSubmatch(trimmed)
+			p.blocks = append(p.blocks, newHeadingBlock(len(match[1]), parseInline(match[2])))
+			p.pos++
+
+		case strings.HasPrefix(trimmed, ">") && !isSpoilerLine(trimmed):
+			p.flushParagraph()
+			p.parseQuote()
+
+		case listItemPattern.MatchString(line):
+			p.flushParagraph()
+			p.parseList()
+
+		case strings.Contains(line, "|") && p.pos+1 < len(p.lines) && tableDividerRegexp.MatchString(p.lines[p.pos+1]) && strings.Contains(p.lines[p.pos+1], "-"):
+			p.flushParagraph()
+			p.parseTable()
+
+		default:
+			p.paragraph = append(p.paragraph, line)
+			p.pos++
+		}
+	}
+
+	p.flushParagraph()
+	return p.blocks
+}
+
+// flushParagraph emits the pending paragraph lines as one paragraph block
+func (p *markdownParser) flushParagraph() {
+	if len(p.paragraph) == 0 {
+		return
+	}
+	text := joinParagraphLines(p.paragraph)
+	p.paragraph = nil
+	if strings.TrimSpace(text) == "" {
+		return
+	}
+	p.blocks = append(p.blocks, newParagraphBlock(parseInline(text)))
+}
+
+// flushParagraphAsHeading emits the pending paragraph lines as a setext heading
+func (p *markdownParser) flushParagraphAsHeading(level int) {
+	text := joinParagraphLines(p.paragraph)
+	p.paragraph = nil
+	p.blocks = append(p.blocks, newHeadingBlock(level, parseInline(text)))
+}
+
+// joinParagraphLines joins soft-wrapped lines, keeping hard breaks (two trailing spaces or a backslash)
+func joinParagraphLines(lines []string) string {
+	var sb strings.Builder
+	for i, line := range lines {
+		hardBreak := strings.HasSuffix(line, "  ") || strings.HasSuffix(line, "\\")
+		line = strings.TrimSpace(line)
+		if hardBreak {
+			line = strings.TrimSuffix(line, "\\")
+		}
+		sb.WriteString(line)
+		if i < len(lines)-1 {
+			if hardBreak {
+				sb.WriteString("\n")
+			} else {
+				sb.WriteString(" ")
+			}
+		}
+	}
+	return sb.String()
+}
+
+// parseFencedCode consumes a fenced code block starting at the current line
+func (p *markdownParser) parseFencedCode(fence, info string) {
+	p.pos++
+	var code []string
+	for p.pos < len(p.lines) {
+		if strings.HasPrefix(strings.TrimSpace(p.lines[p.pos]), fence) {
+			p.pos++
+			break
+		}
+		code = append(code, p.lines[p.pos])
+		p.pos++
+	}
+
+	language := ""
+	if fields := strings.Fields(info); len(fields) > 0 {
+		language = fields[0]
+	}
+	p.blocks = append(p.blocks, newCodeBlocks(strings.Join(code, "\n"), language)...)
+}
+
+// isIndentedCode reports whether the line is part of a four-space indented code block
+func isIndentedCode(line string) bool {
+	return (strings.HasPrefix(line, "    ") || strings.HasPrefix(line, "\t")) && strings.TrimSpace(line) != ""
+}
+
+// parseIndentedCode consumes consecutive indented lines as a code block
+func (p *markdownParser) parseIndentedCode() {
+	var code []string
+	for p.pos < len(p.lines) {
+		line := p.lines[p.pos]
+		if strings.TrimSpace(line) == "" {
+			// Blank lines only continue the block if more indented code follows
+			next := p.pos + 1
+			for next < len(p.lines) && strings.TrimSpace(p.lines[next]) == "" {
+				next++
+			}
+			if next >= len(p.lines) || !isIndentedCode(p.lines[next]) {
+				break
+			}
+			code = append(code, "")
+			p.pos++
+			continue
+		}
+		if !isIndentedCode(line) {
+			break
+		}
+		if strings.HasPrefix(line, "\t") {
+			line = line[1:]
+		} else {
+			line = line[4:]
+		}
+		code = append(code, line)
+		p.pos++
+	}
+	p.blocks = append(p.blocks, newCodeBlocks(strings.Join(code, "\n"), "")...)
+}
+
+// isSpoilerLine reports whether a line that starts with ">" is actually a >!spoiler!<
+func isSpoilerLine(trimmed string) bool {
+	return strings.HasPrefix(trimmed, ">!") && strings.Contains(trimmed, "!<")
+}
+
+// parseQuote consumes consecutive quoted lines into a single quote block
+func (p *markdownParser) parseQuote() {
+	var quoted []string
+	for p.pos < len(p.lines) {
+		trimmed := strings.TrimSpace(p.lines[p.pos])
+		if !strings.HasPrefix(trimmed, ">") || isSpoilerLine(trimmed) {
+			break
+		}
+		quoted = append(quoted, strings.TrimSpace(strings.TrimLeft(trimmed, ">")))
+		p.pos++
+	}
+
+	// Blank quoted lines separate paragraphs inside the quote
+	var paragraphs []string
+	var current []string
+	for _, line := range quoted {
+		if line == "" {
+			if len(current) > 0 {
+				paragraphs = append(paragraphs, joinParagraphLines(current))
+				current = nil
+			}
+			continue
+		}
+		current = append(current, line)
+	}
+	if len(current) > 0 {
+		paragraphs = append(paragraphs, joinParagraphLines(current))
+	}
+	if len(paragraphs) == 0 {
+		return
+	}
+
+	p.blocks = append(p.blocks, newQuoteBlock(parseInline(strings.Join(paragraphs, "\n"))))
+}
+
+// listItem is an intermediate representation of a parsed list item
+type listItem struct {
+	indent   int
+	ordered  bool
+	text     string
+	children []*listItem
+}
+
+// parseList consumes a (possibly nested) list starting at the current line
+func (p *markdownParser) parseList() {
+	var roots []*listItem
+	var stack []*listItem
+
+	for p.pos < len(p.lines) {
+		line := p.lines[p.pos]
+		if strings.TrimSpace(line) == "" {
+			// A blank line ends the list unless another item follows
+			if p.pos+1 < len(p.lines) && listItemPattern.MatchString(p.lines[p.pos+1]) {
+				p.pos++
+				continue
+			}
+			break
+		}
+
+		match := listItemPattern.FindStringSubmatch(line)
+		if match == nil || hrPattern.MatchString(line) {
+			// Lazy continuation of the previous item's text
+			if len(stack) == 0 || (!strings.HasPrefix(line, " ") && !strings.HasPrefix(line, "\t") && p.startsBlock(line)) {
+				break
+			}
+			last := stack[len(stack)-1]
+			last.text += " " + strings.TrimSpace(line)
+			p.pos++
+			continue
+		}
+
+		item := &listItem{
+			indent:  indentWidth(match[1]),
+			ordered: match[2] != "-" && match[2] != "*" && match[2] != "+",
+			text:    match[3],
+		}
+
+		for len(stack) > 0 && stack[len(stack)-1].indent >= item.indent {
+			stack = stack[:len(stack)-1]
+		}
+		if len(stack) == 0 {
+			roots = append(roots, item)
+		} else {
+			parent := stack[len(stack)-1]
+			parent.children = append(parent.children, item)
+		}
+		stack = append(stack, item)
+		p.pos++
+	}
+
+	p.blocks = append(p.blocks, listItemsToBlocks(roots, p.depth)...)
+}
+
+// startsBlock reports whether an unindented line would start a new block
+func (p *markdownParser) startsBlock(line string) bool {
+	trimmed := strings.TrimSpace(line)
+	return headingPattern.MatchString(trimmed) ||
+		strings.HasPrefix(trimmed, ">") ||
+		strings.HasPrefix(trimmed, "```") ||
+		strings.HasPrefix(trimmed, "~~~") ||
+		hrPattern.MatchString(line)
+}
+
+// indentWidth measures leading whitespace, counting tabs as four spaces
+func indentWidth(prefix string) int {
+	width := 0
+	for _, r := range prefix {
+		if r == '\t' {
+			width += 4
+		} else {
+			width++
+		}
+	}
+	return width
+}
+
+// listItemsToBlocks converts list items to blocks, flattening anything deeper
+// than depth allows and sub-items past the most children Notion accepts
+func listItemsToBlocks(items []*listItem, depth int) []notionapi.Block {
+	blocks := []notionapi.Block{}
+	for _, item := range items {
+		var children []notionapi.Block
+		var flattened []notionapi.Block
+		if len(item.children) > 0 {
+			if depth > 0 {
+				children = listItemsToBlocks(item.children, depth-1)
+				if len(children) > maxChildrenPerRequest {
+					children, flattened = children[:maxChildrenPerRequest], children[maxChildrenPerRequest:]
+				}
+			} else {
+				flattened = listItemsToBlocks(item.children, 0)
+			}
+		}
+		blocks = append(blocks, newListItemBlock(item.ordered, parseInline(item.text), children))
+		blocks = append(blocks, flattened...)
+	}
+	return blocks
+}
+
+// parseTable consumes a pipe table (header, divider and body rows)
+func (p *markdownParser) parseTable() {
+	header := splitTableRow(p.lines[p.pos])
+	p.pos += 2 // header and divider
+
+	rows := [][]string{header}
+	for p.pos < len(p.lines) {
+		line := p.lines[p.pos]
+		if strings.TrimSpace(line) == "" || !strings.Contains(line, "|") {
+			break
+		}
+		rows = append(rows, splitTableRow(line))
+		p.pos++
+	}
+
+	width := len(header)
+
+	// Tables need a level of nesting for their rows; fall back to plain rows otherwise
+	if p.depth == 0 {
+		for _, row := range rows {
+			p.blocks = append(p.blocks, newParagraphBlock(parseInline(strings.Join(row, " | "))))
+		}
+		return
+	}
+
+	tableRows := make([]notionapi.Block, 0, len(rows))
+	for _, row := range rows {
+		cells := make([][]notionapi.RichText, width)
+		for i := 0; i < width; i++ {
+			if i < len(row) {
+				cells[i] = parseInline(row[i])
+			} else {
+				cells[i] = []notionapi.RichText{}
+			}
+		}
+		tableRows = append(tableRows, notionapi.TableRowBlock{
+			BasicBlock: notionapi.BasicBlock{
+				Object: notionapi.ObjectTypeBlock,
+				Type:   notionapi.BlockTypeTableRowBlock,
+			},
+			TableRow: notionapi.TableRow{Cells: cells},
+		})
+	}
+
+	// A table holds at most maxChildrenPerRequest rows; continue longer ones
+	// in further tables, each repeating the header
+	headerRow, bodyRows := tableRows[0], tableRows[1:]
+	for start := 0; start == 0 || start < len(bodyRows); start += maxChildrenPerRequest - 1 {
+		end := start + maxChildrenPerRequest - 1
+		if end > len(bodyRows) {
+			end = len(bodyRows)
+		}
+		p.blocks = append(p.blocks, notionapi.TableBlock{
+			BasicBlock: notionapi.BasicBlock{
+				Object: notionapi.ObjectTypeBlock,
+				Type:   notionapi.BlockTypeTableBlock,
+			},
+			Table: notionapi.Table{
+				TableWidth:      width,
+				HasColumnHeader: true,
+				Children:        append([]notionapi.Block{headerRow}, bodyRows[start:end]...),
+			},
+		})
+	}
+}
+
+// splitTableRow splits a pipe table row into trimmed cells, honouring escaped pipes
+func splitTableRow(line string) []string {
+	line = strings.TrimSpace(line)
+	line = strings.TrimPrefix(line, "|")
+	if strings.HasSuffix(line, "|") && !strings.HasSuffix(line, "\\|") {
+		line = strings.TrimSuffix(line, "|")
+	}
+
+	var cells []string
+	var sb strings.Builder
+	for i := 0; i < len(line); i++ {
+		if line[i] == '\\' && i+1 < len(line) && line[i+1] == '|' {
+			sb.WriteByte('|')
+			i++
+			continue
+		}
+		if line[i] == '|' {
+			cells = append(cells, strings.TrimSpace(sb.String()))
+			sb.Reset()
+			continue
+		}
+		sb.WriteByte(line[i])
+	}
+	cells = append(cells, strings.TrimSpace(sb.String()))
+	return cells
+}
+
+// inlineStyle tracks the annotations active while parsing inline markdown
+type inlineStyle struct {
+	bold          bool
+	italic        bool
+	strikethrough bool
+	code          bool
+	spoiler       bool
+	link          string
+}
+
+// inlineSpan is a run of text sharing a single style
+type inlineSpan struct {
+	text  string
+	style inlineStyle
+}
+
+// parseInline converts inline markdown into Notion rich text
+func parseInline(text string) []notionapi.RichText {
+	var spans []inlineSpan
+	parseInlineSpans(text, inlineStyle{}, &spans)
+	return spansToRichText(mergeSpans(spans))
+}
+
+// parseInlineSpans walks text and appends styled spans to out
+func parseInlineSpans(text string, style inlineStyle, out *[]inlineSpan) {
+	var plain strings.Builder
+	flush := func() {
+		if plain.Len() > 0 {
+			*out = append(*out, inlineSpan{text: plain.String(), style: style})
+			plain.Reset()
+		}
+	}
+
+	for i := 0; i < len(text); {
+		rest := text[i:]
+		prev := previousRune(text, i)
+
+		switch {
+		// Backslash escapes for markdown punctuation
+		case rest[0] == '\\' && len(rest) > 1 && strings.ContainsRune("\\`*_{}[]()#+-.!|~>^", rune(rest[1])):
+			plain.WriteByte(rest[1])
+			i += 2
+			continue
+
+		case rest[0] == '`':
+			ticks := len(rest) - len(strings.TrimLeft(rest, "`"))
+			fence := rest[:ticks]
+			if end := strings.Index(rest[ticks:], fence); end >= 0 {
+				flush()
+				codeStyle := style
+				codeStyle.code = true
+				*out = append(*out, inlineSpan{text: strings.TrimSpace(rest[ticks : ticks+end]), style: codeStyle})
+				i += ticks + end + ticks
+				continue
+			}
+
+		case strings.HasPrefix(rest, ">!"):
+			if end := strings.Index(rest[2:], "!<"); end >= 0 {
+				flush()
+				inner := style
+				inner.spoiler = true
+				parseInlineSpans(rest[2:2+end], inner, out)
+				i += 2 + end + 2
+				continue
+			}
+
+		case strings.HasPrefix(rest, "**") || strings.HasPrefix(rest, "__"):
+			delim := rest[:2]
+			if end := findClosingDelimiter(rest[2:], delim); end > 0 && (delim == "**" || !isWordRune(prev)) {
+				flush()
+				inner := style
+				inner.bold = true
+				parseInlineSpans(rest[2:2+end], inner, out)
+				i += 2 + end + 2
+				continue
+			}
+
+		case strings.HasPrefix(rest, "~~"):
+			if end := findClosingDelimiter(rest[2:], "~~"); end > 0 {
+				flush()
+				inner := style
+				inner.strikethrough = true
+				parseInlineSpans(rest[2:2+end], inner, out)
+				i += 2 + end + 2
+				continue
+			}
+
+		case rest[0] == '*' || rest[0] == '_':
+			delim := rest[:1]
+			if end := findClosingDelimiter(rest[1:], delim); end > 0 && (delim == "*" || !isWordRune(prev)) {
+				// Underscores inside words (snake_case) are not emphasis
+				after, _ := utf8.DecodeRuneInString(rest[1+end+1:])
+				if delim == "*" || !isWordRune(after) {
+					flush()
+					inner := style
+					inner.italic = true
+					parseInlineSpans(rest[1:1+end], inner, out)
+					i += 1 + end + 1
+					continue
+				}
+			}
+
+		case rest[0] == '[':
+			if label, target, n, ok := parseLink(rest); ok {
+				flush()
+				inner := style
+				inner.link = normalizeLinkURL(target)
+				parseInlineSpans(label, inner, out)
+				i += n
+				continue
+			}
+
+		case (strings.HasPrefix(rest, "http://") || strings.HasPrefix(rest, "https://")) && !isWordRune(prev) && style.link == "":
+			url := bareURL(rest)
+			flush()
+			inner := style
+			inner.link = url
+			*out = append(*out, inlineSpan{text: url, style: inner})
+			i += len(url)
+			continue
+
+		case (rest[0] == 'r' || rest[0] == 'u' || rest[0] == '/') && !isWordRune(prev) && prev != '/' && style.link == "":
+			if match := subredditPattern.FindStringSubmatch(rest); match != nil {
+				flush()
+				inner := style
+				if match[1] == "r" {
+					inner.link = "https://www.reddit.com/r/" + match[2]
+				} else {
+					inner.link = "https://www.reddit.com/user/" + match[2]
+				}
+				*out = append(*out, inlineSpan{text: match[0], style: inner})
+				i += len(match[0])
+				continue
+			}
+		}
+
+		r, size := utf8.DecodeRuneInString(rest)
+		plain.WriteRune(r)
+		i += size
+	}
+
+	flush()
+}
+
+// findClosingDelimiter returns the index of the closing delimiter, or -1.
+// The opening delimiter must not be followed by whitespace, nor the closing one preceded by it.
+func findClosingDelimiter(text, delim string) int {
+	if text == "" || unicode.IsSpace(rune(text[0])) {
+		return -1
+	}
+	for i := 1; i+len(delim) <= len(text); i++ {
+		if text[i-1] == '\\' {
+			continue
+		}
+		if strings.HasPrefix(text[i:], delim) && !unicode.IsSpace(rune(text[i-1])) {
+			// Don't let "*" close on the first half of a "**"
+			if len(delim) == 1 && i+1 < len(text) && text[i+1] == delim[0] {
+				i++
+				continue
+			}
+			return i
+		}
+	}
+	return -1
+}
+
+// parseLink parses [label](target) at the start of text, returning the consumed length
+func parseLink(text string) (label, target string, n int, ok bool) {
+	depth := 0
+	closeBracket := -1
+	for i := 0; i < len(text); i++ {
+		switch text[i] {
+		case '\\':
+			i++
+		case '[':
+			depth++
+		case ']':
+			depth--
+			if depth == 0 {
+				closeBracket = i
+			}
+		}
+		if closeBracket >= 0 {
+			break
+		}
+	}
+	if closeBracket < 0 || closeBracket+1 >= len(text) || text[closeBracket+1] != '(' {
+		return "", "", 0, false
+	}
+
+	depth = 0
+	for i := closeBracket + 1; i < len(text); i++ {
+		switch text[i] {
+		case '(':
+			depth++
+		case ')':
+			depth--
+			if depth == 0 {
+				target = strings.TrimSpace(text[closeBracket+2 : i])
+				// Drop an optional "title"
+				if idx := strings.Index(target, " \""); idx >= 0 {
+					target = target[:idx]
+				}
+				return text[1:closeBracket], strings.Trim(target, "<>"), i + 1, true
+			}
+		}
+	}
+	return "", "", 0, false
+}
+
+// normalizeLinkURL makes Reddit-relative links absolute and drops links Notion would reject
+func normalizeLinkURL(target string) string {
+	switch {
+	case strings.HasPrefix(target, "http://"), strings.HasPrefix(target, "https://"), strings.HasPrefix(target, "mailto:"):
+		return target
+	case strings.HasPrefix(target, "/"):
+		return "https://www.reddit.com" + target
+	case subredditPattern.MatchString(target):
+		return "https://www.reddit.com/" + target
+	default:
+		return ""
+	}
+}
+
+// bareURL returns the URL at the start of text, without trailing punctuation
+func bareURL(text string) string {
+	end := strings.IndexFunc(text, func(r rune) bool {
+		return unicode.IsSpace(r) || r == '<' || r == '>' || r == '"'
+	})
+	if end < 0 {
+		end = len(text)
+	}
+	url := strings.TrimRight(text[:end], ".,;:!?'")
+	// Keep balanced parentheses (e.g. Wikipedia links) but drop a trailing unmatched one
+	if strings.HasSuffix(url, ")") && strings.Count(url, "(") < strings.Count(url, ")") {
+		url = strings.TrimSuffix(url, ")")
+	}
+	return url
+}
+
+// previousRune returns the rune before byte offset i, or a space at the start
+func previousRune(text string, i int) rune {
+	if i == 0 {
+		return ' '
+	}
+	r, _ := utf8.DecodeLastRuneInString(text[:i])
+	return r
+}
+
+// isWordRune reports whether r is a letter, digit or underscore
+func isWordRune(r rune) bool {
+	return r == '_' || unicode.IsLetter(r) || unicode.IsDigit(r)
+}
+
+// mergeSpans joins adjacent spans that share a style
+func mergeSpans(spans []inlineSpan) []inlineSpan {
+	merged := make([]inlineSpan, 0, len(spans))
+	for _, span := range spans {
+		if span.text == "" {
+			continue
+		}
+		if n := len(merged); n > 0 && merged[n-1].style == span.style {
+			merged[n-1].text += span.text
+			continue
+		}
+		merged = append(merged, span)
+	}
+	return merged
+}
+
+// spansToRichText converts spans to rich text, splitting each span at the per-object length limit
+func spansToRichText(spans []inlineSpan) []notionapi.RichText {
+	richText := []notionapi.RichText{}
+	for _, span := range spans {
+		for _, chunk := range splitRunes(span.text, maxRichTextLength) {
+			text := &notionapi.Text{Content: chunk}
+			if span.style.link != "" {
+				text.Link = &notionapi.Link{Url: span.style.link}
+			}
+
+			rt := notionapi.RichText{
+				Type: notionapi.ObjectTypeText,
+				Text: text,
+			}
+			if span.style != (inlineStyle{link: span.style.link}) {
+				rt.Annotations = &notionapi.Annotations{
+					Bold:          span.style.bold,
+					Italic:        span.style.italic,
+					Strikethrough: span.style.strikethrough,
+					Code:          span.style.code,
+				}
+				if span.style.spoiler {
+					rt.Annotations.Color = notionapi.Color("gray_background")
+				}
+			}
+			richText = append(richText, rt)
+		}
+	}
+
+	// Notion caps rich text objects per block; fold the overflow into plain text
+	if len(richText) > maxRichTextItems {
+		var overflow strings.Builder
+		for _, rt := range richText[maxRichTextItems-1:] {
+			overflow.WriteString(rt.Text.Content)
+		}
+		richText = richText[:maxRichTextItems-1]
+		for _, chunk := range splitRunes(overflow.String(), maxRichTextLength) {
+			if len(richText) == maxRichTextItems {
+				break
+			}
+			richText = append(richText, notionapi.RichText{
+				Type: notionapi.ObjectTypeText,
+				Text: &notionapi.Text{Content: chunk},
+			})
+		}
+	}
+
+	return richText
+}
+
+// splitRunes splits text into chunks of at most size characters
+func splitRunes(text string, size int) []string {
+	runes := []rune(text)
+	if len(runes) <= size {
+		return []string{text}
+	}
+	var chunks []string
+	for len(runes) > 0 {
+		n := size
+		if len(runes) < n {
+			n = len(runes)
+		}
+		chunks = append(chunks, string(runes[:n]))
+		runes = runes[n:]
+	}
+	return chunks
+}
+
+// newParagraphBlock creates a paragraph block from rich text
+func newParagraphBlock(richText []notionapi.RichText) notionapi.ParagraphBlock {
+	return notionapi.ParagraphBlock{
+		BasicBlock: notionapi.BasicBlock{
+			Object: notionapi.ObjectTypeBlock,
+			Type:   notionapi.BlockTypeParagraph,
+		},
+		Paragraph: notionapi.Paragraph{RichText: richText},
+	}
+}
+
+// newHeadingBlock creates a heading block; Notion only has three levels so deeper headings collapse to h3
+func newHeadingBlock(level int, richText []notionapi.RichText) notionapi.Block {
+	heading := notionapi.Heading{RichText: richText}
+	switch level {
+	case 1:
+		return notionapi.Heading1Block{
+			BasicBlock: notionapi.BasicBlock{Object: notionapi.ObjectTypeBlock, Type: notionapi.BlockTypeHeading1},
+			Heading1:   heading,
+		}
+	case 2:
+		return notionapi.Heading2Block{
+			BasicBlock: notionapi.BasicBlock{Object: notionapi.ObjectTypeBlock, Type: notionapi.BlockTypeHeading2},
+			Heading2:   heading,
+		}
+	default:
+		return notionapi.Heading3Block{
+			BasicBlock: notionapi.BasicBlock{Object: notionapi.ObjectTypeBlock, Type: notionapi.BlockTypeHeading3},
+			Heading3:   heading,
+		}
+	}
+}
+
+// newListItemBlock creates a bulleted or numbered list item
+func newListItemBlock(ordered bool, richText []notionapi.RichText, children []notionapi.Block) notionapi.Block {
+	item := notionapi.ListItem{RichText: richText, Children: children}
+	if ordered {
+		return notionapi.NumberedListItemBlock{
+			BasicBlock:       notionapi.BasicBlock{Object: notionapi.ObjectTypeBlock, Type: notionapi.BlockTypeNumberedListItem},
+			NumberedListItem: item,
+		}
+	}
+	return notionapi.BulletedListItemBlock{
+		BasicBlock:       notionapi.BasicBlock{Object: notionapi.ObjectTypeBlock, Type: notionapi.BlockTypeBulletedListItem},
+		BulletedListItem: item,
+	}
+}
+
+// newQuoteBlock creates a quote block
+func newQuoteBlock(richText []notionapi.RichText) notionapi.QuoteBlock {
+	return notionapi.QuoteBlock{
+		BasicBlock: notionapi.BasicBlock{Object: notionapi.ObjectTypeBlock, Type: notionapi.BlockTypeQuote},
+		Quote:      notionapi.Quote{RichText: richText},
+	}
+}
+
+// newCodeBlocks creates a code block, splitting long code across rich text
+// objects and, past what one block holds, across consecutive code blocks
+func newCodeBlocks(code, language string) []notionapi.Block {
+	lang, ok := codeLanguages[strings.ToLower(language)]
+	if !ok {
+		lang = "plain text"
+	}
+
+	chunks := splitRunes(code, maxRichTextLength)
+	blocks := make([]notionapi.Block, 0, (len(chunks)+maxRichTextItems-1)/maxRichTextItems)
+	for start := 0; start < len(chunks); start += maxRichTextItems {
+		end := start + maxRichTextItems
+		if end > len(chunks) {
+			end = len(chunks)
+		}
+
+		richText := make([]notionapi.RichText, 0, end-start)
+		for _, chunk := range chunks[start:end] {
+			richText = append(richText, notionapi.RichText{
+				Type: notionapi.ObjectTypeText,
+				Text: &notionapi.Text{Content: chunk},
+			})
+		}
+		blocks = append(blocks, notionapi.CodeBlock{
+			BasicBlock: notionapi.BasicBlock{Object: notionapi.ObjectTypeBlock, Type: notionapi.BlockTypeCode},
+			Code: notionapi.Code{
+				RichText: richText,
+				Language: lang,
+			},
+		})
+	}
+	return blocks
+}
+
+// newDividerBlock creates a divider block
+func newDividerBlock() notionapi.DividerBlock {
+	return notionapi.DividerBlock{
+		BasicBlock: notionapi.BasicBlock{Object: notionapi.ObjectTypeBlock, Type: notionapi.BlockTypeDivider},
+	}
+}
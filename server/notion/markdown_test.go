@@ -0,0 +1,318 @@
+package notion
+
+import (
+	"fmt"
+	"reflect"
+	"strings"
+	"testing"
+
+	"github.com/jomei/notionapi"
+)
+
+// render describes blocks one per line, children indented by two spaces,
+// with rich text in a markdown-like shorthand
+func render(blocks []notionapi.Block) []string {
+	var lines []string
+	var walk func(blocks []notionapi.Block, indent string)
+	walk = func(blocks []notionapi.Block, indent string) {
+		for _, block := range blocks {
+			var line string
+			var children []notionapi.Block
+			switch b := block.(type) {
+			case notionapi.ParagraphBlock:
+				line = "p: " + renderRichText(b.Paragraph.RichText)
+			case notionapi.Heading1Block:
+				line = "h1: " + renderRichText(b.Heading1.RichText)
+			case notionapi.Heading2Block:
+				line = "h2: " + renderRichText(b.Heading2.RichText)
+			case notionapi.Heading3Block:
+				line = "h3: " + renderRichText(b.Heading3.RichText)
+			case notionapi.BulletedListItemBlock:
+				line, children = "ul: "+renderRichText(b.BulletedListItem.RichText), b.BulletedListItem.Children
+			case notionapi.NumberedListItemBlock:
+				line, children = "ol: "+renderRichText(b.NumberedListItem.RichText), b.NumberedListItem.Children
+			case notionapi.QuoteBlock:
+				line = "quote: " + renderRichText(b.Quote.RichText)
+			case notionapi.CodeBlock:
+				line = fmt.Sprintf("code(%s): %s", b.Code.Language, renderRichText(b.Code.RichText))
+			case notionapi.DividerBlock:
+				line = "hr"
+			case notionapi.TableBlock:
+				line, children = fmt.Sprintf("table(%d)", b.Table.TableWidth), b.Table.Children
+			case notionapi.TableRowBlock:
+				cells := make([]string, len(b.TableRow.Cells))
+				for i, cell := range b.TableRow.Cells {
+					cells[i] = renderRichText(cell)
+				}
+				line = "row: " + strings.Join(cells, " | ")
+			default:
+				line = fmt.Sprintf("%T", block)
+			}
+			lines = append(lines, indent+line)
+			walk(children, indent+"  ")
+		}
+	}
+	walk(blocks, "")
+	return lines
+}
+
+func renderRichText(richText []notionapi.RichText) string {
+	var sb strings.Builder
+	for _, rt := range richText {
+		text := rt.Text.Content
+		if a := rt.Annotations; a != nil {
+			if a.Code {
+				text = "`" + text + "`"
+			}
+			if a.Bold {
+				text = "**" + text + "**"
+			}
+			if a.Italic {
+				text = "_" + text + "_"
+			}
+			if a.Strikethrough {
+				text = "~~" + text + "~~"
+			}
+			if a.Color != "" {
+				text = "{" + string(a.Color) + ":" + text + "}"
+			}
+		}
+		if rt.Text.Link != nil {
+			text = "[" + text + "](" + rt.Text.Link.Url + ")"
+		}
+		sb.WriteString(text)
+	}
+	return sb.String()
+}
+
+func TestMarkdownToBlocks(t *testing.T) {
+	tests := []struct {
+		name     string
+		markdown string
+		want     []string
+	}{
+		{
+			name:     "paragraphs",
+			markdown: "First line\nsame paragraph\n\nSecond paragraph",
+			want:     []string{"p: First line same paragraph", "p: Second paragraph"},
+		},
+		{
+			name:     "hard break",
+			markdown: "one  \ntwo",
+			want:     []string{"p: one\ntwo"},
+		},
+		{
+			name:     "atx headings",
+			markdown: "# One\n## Two ##\n### Three\n#### Four",
+			want:     []string{"h1: One", "h2: Two", "h3: Three", "h3: Four"},
+		},
+		{
+			name:     "setext headings",
+			markdown: "Title\n=====\nSubtitle\n---",
+			want:     []string{"h1: Title", "h2: Subtitle"},
+		},
+		{
+			name:     "divider",
+			markdown: "above\n\n***\n\nbelow",
+			want:     []string{"p: above", "hr", "p: below"},
+		},
+		{
+			name:     "bulleted and numbered lists",
+			markdown: "- one\n* two\n\n1. first\n2) second",
+			want:     []string{"ul: one", "ul: two", "ol: first", "ol: second"},
+		},
+		{
+			name:     "nested list",
+			markdown: "- a\n  - b\n- c",
+			want:     []string{"ul: a", "  ul: b", "ul: c"},
+		},
+		{
+			name:     "list nesting deeper than allowed is flattened",
+			markdown: "- a\n  - b\n    - c\n      - d",
+			want:     []string{"ul: a", "  ul: b", "    ul: c", "    ul: d"},
+		},
+		{
+			name:     "lazy list continuation",
+			markdown: "- item\ncontinued",
+			want:     []string{"ul: item continued"},
+		},
+		{
+			name:     "fenced code",
+			markdown: "```golang\nfunc main() {\n\t*x = 1\n}\n```",
+			want:     []string{"code(go): func main() {\n\t*x = 1\n}"},
+		},
+		{
+			name:     "fenced code with unknown language",
+			markdown: "~~~brainfuck\n+++.\n~~~",
+			want:     []string{"code(plain text): +++."},
+		},
+		{
+			name:     "indented code",
+			markdown: "text\n\n    a := 1\n\n    b := 2\n\nafter",
+			want:     []string{"p: text", "code(plain text): a := 1\n\nb := 2", "p: after"},
+		},
+		{
+			name:     "quote",
+			markdown: "> quoted\n> text",
+			want:     []string{"quote: quoted text"},
+		},
+		{
+			name:     "table",
+			markdown: "| a | b |\n|---|:-:|\n| 1 | 2 \\| 3 |\n| 4 |",
+			want:     []string{"table(2)", "  row: a | b", "  row: 1 | 2 | 3", "  row: 4 | "},
+		},
+		{
+			name:     "inline annotations",
+			markdown: "**bold** *italic* _also_ ~~gone~~ `code` >!secret!<",
+			want:     []string{"p: **bold** _italic_ _also_ ~~gone~~ `code` {gray_background:secret}"},
+		},
+		{
+			name:     "nested annotations",
+			markdown: "**bold *and italic* end**",
+			want:     []string{"p: **bold **_**and italic**_** end**"},
+		},
+		{
+			name:     "snake_case is not emphasis",
+			markdown: "use snake_case_names here",
+			want:     []string{"p: use snake_case_names here"},
+		},
+		{
+			name:     "links",
+			markdown: "[docs](https://go.dev) and [wiki](/r/golang/wiki) and https://example.com/a_(b).",
+			want:     []string{"p: [docs](https://go.dev) and [wiki](https://www.reddit.com/r/golang/wiki) and [https://example.com/a_(b)](https://example.com/a_(b))."},
+		},
+		{
+			name:     "subreddit and user mentions",
+			markdown: "see r/golang and /u/spez",
+			want:     []string{"p: see [r/golang](https://www.reddit.com/r/golang) and [/u/spez](https://www.reddit.com/user/spez)"},
+		},
+		{
+			name:     "escapes and entities",
+			markdown: `\*not italic\* &amp; &lt;tag&gt;`,
+			want:     []string{"p: *not italic* & <tag>"},
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got := render(MarkdownToBlocks(tt.markdown))
+			if !reflect.DeepEqual(got, tt.want) {
+				t.Errorf("MarkdownToBlocks(%q)\n got: %q\nwant: %q", tt.markdown, got, tt.want)
+			}
+		})
+	}
+}
+
+func TestMarkdownToBlocksWithoutNesting(t *testing.T) {
+	got := render(convertMarkdown("- a\n  - b\n\n| x | y |\n|---|---|\n| 1 | 2 |", 0))
+	want := []string{"ul: a", "ul: b", "p: x | y", "p: 1 | 2"}
+	if !reflect.DeepEqual(got, want) {
+		t.Errorf("got %q, want %q", got, want)
+	}
+}
+
+func TestMarkdownLimits(t *testing.T) {
+	t.Run("long text is split into 2000 character rich text objects", func(t *testing.T) {
+		blocks := MarkdownToBlocks(strings.Repeat("é", 4500))
+		richText := blocks[0].(notionapi.ParagraphBlock).Paragraph.RichText
+		if got := richTextLengths(richText); !reflect.DeepEqual(got, []int{2000, 2000, 500}) {
+			t.Errorf("rich text lengths = %v, want [2000 2000 500]", got)
+		}
+	})
+
+	t.Run("rich text past 100 objects is folded into plain text", func(t *testing.T) {
+		words := make([]string, 150)
+		for i := range words {
+			words[i] = fmt.Sprintf("**w%d**", i)
+		}
+		blocks := MarkdownToBlocks(strings.Join(words, " "))
+		richText := blocks[0].(notionapi.ParagraphBlock).Paragraph.RichText
+		if len(richText) > maxRichTextItems {
+			t.Fatalf("%d rich text objects, want at most %d", len(richText), maxRichTextItems)
+		}
+		var text strings.Builder
+		for _, rt := range richText {
+			text.WriteString(rt.Text.Content)
+		}
+		if !strings.HasSuffix(text.String(), "w149") {
+			t.Errorf("text was lost: ends with %q", text.String()[text.Len()-10:])
+		}
+	})
+
+	t.Run("long code is split across code blocks", func(t *testing.T) {
+		code := strings.Repeat("x", maxRichTextItems*maxRichTextLength+10)
+		blocks := MarkdownToBlocks("```\n" + code + "\n```")
+		if len(blocks) != 2 {
+			t.Fatalf("%d blocks, want 2", len(blocks))
+		}
+		total := 0
+		for _, block := range blocks {
+			richText := block.(notionapi.CodeBlock).Code.RichText
+			if len(richText) > maxRichTextItems {
+				t.Errorf("code block has %d rich text objects", len(richText))
+			}
+			for _, n := range richTextLengths(richText) {
+				if n > maxRichTextLength {
+					t.Errorf("rich text object of %d characters", n)
+				}
+				total += n
+			}
+		}
+		if total != len(code) {
+			t.Errorf("code blocks hold %d characters, want %d", total, len(code))
+		}
+	})
+
+	t.Run("long tables continue in further tables", func(t *testing.T) {
+		var sb strings.Builder
+		sb.WriteString("| n | square |\n|---|---|\n")
+		for i := 0; i < 150; i++ {
+			fmt.Fprintf(&sb, "| %d | %d |\n", i, i*i)
+		}
+		blocks := MarkdownToBlocks(sb.String())
+		if len(blocks) != 2 {
+			t.Fatalf("%d blocks, want 2 tables", len(blocks))
+		}
+		rows := 0
+		for _, block := range blocks {
+			children := block.(notionapi.TableBlock).Table.Children
+			if len(children) > maxChildrenPerRequest {
+				t.Errorf("table has %d rows", len(children))
+			}
+			if header := render(children[:1])[0]; header != "row: n | square" {
+				t.Errorf("table starts with %q, want the header", header)
+			}
+			rows += len(children) - 1
+		}
+		if rows != 150 {
+			t.Errorf("tables hold %d body rows, want 150", rows)
+		}
+	})
+
+	t.Run("sub-items past 100 follow their parent", func(t *testing.T) {
+		var sb strings.Builder
+		sb.WriteString("- parent\n")
+		for i := 0; i < 150; i++ {
+			fmt.Fprintf(&sb, "  - child %d\n", i)
+		}
+		blocks := MarkdownToBlocks(sb.String())
+		if len(blocks) != 51 {
+			t.Fatalf("%d blocks, want the parent and 50 flattened items", len(blocks))
+		}
+		children := blocks[0].(notionapi.BulletedListItemBlock).BulletedListItem.Children
+		if len(children) != maxChildrenPerRequest {
+			t.Errorf("parent has %d children, want %d", len(children), maxChildrenPerRequest)
+		}
+		if got := render(blocks[1:2])[0]; got != "ul: child 100" {
+			t.Errorf("first flattened item = %q, want child 100", got)
+		}
+	})
+}
+
+func richTextLengths(richText []notionapi.RichText) []int {
+	lengths := make([]int, len(richText))
+	for i, rt := range richText {
+		lengths[i] = len([]rune(rt.Text.Content))
+	}
+	return lengths
+}
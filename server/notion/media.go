@@ -0,0 +1,165 @@
+package notion
+
+import (
+	"net/url"
+	"path"
+	"strings"
+
+	"re2no/reddit"
+
+	"github.com/jomei/notionapi"
+)
+
+// mediaBlocks returns native Notion blocks for a post's images, gallery or
+// video. Crossposts use the original post's media. Returns nil when the post
+// has no media Notion can show.
+func mediaBlocks(post *reddit.RedditPost) []notionapi.Block {
+	if post == nil {
+		return nil
+	}
+	if len(post.CrosspostParentList) > 0 {
+		if blocks := mediaBlocks(&post.CrosspostParentList[0]); len(blocks) > 0 {
+			return blocks
+		}
+	}
+
+	// Galleries: one image per item, in order, with its caption
+	if images := post.GalleryImages(); len(images) > 0 {
+		blocks := make([]notionapi.Block, 0, len(images))
+		for _, image := range images {
+			blocks = append(blocks, newImageBlock(image.URL, image.Caption))
+		}
+		return blocks
+	}
+
+	// Videos hosted on v.redd.it
+	if post.SecureMedia != nil && post.SecureMedia.RedditVideo != nil {
+		return []notionapi.Block{newVideoBlock(post.SecureMedia.RedditVideo.FallbackURL)}
+	}
+
+	host := mediaHost(post.URL)
+	switch {
+	case host == "youtube.com" || host == "youtu.be":
+		// Notion plays YouTube links in video blocks
+		return []notionapi.Block{newVideoBlock(post.URL)}
+	case host == "imgur.com" && strings.HasSuffix(post.URL, ".gifv"):
+		// .gifv is an HTML page; the MP4 next to it is the actual video
+		return []notionapi.Block{newVideoBlock(strings.TrimSuffix(post.URL, ".gifv") + ".mp4")}
+	case host == "imgur.com" && isImagePath(post.URL):
+		return []notionapi.Block{newImageBlock(post.URL, "")}
+	case host == "imgur.com":
+		// Albums and image pages
+		return []notionapi.Block{newEmbedBlock(post.URL)}
+	case reddit.PostKind(*post) == reddit.KindImage:
+		return []notionapi.Block{newImageBlock(post.URL, "")}
+	case post.SecureMedia != nil && post.SecureMedia.OEmbed != nil:
+		return []notionapi.Block{newEmbedBlock(post.URL)}
+	}
+
+	return nil
+}
+
+// coverImage returns the page cover for a post: its preview image, or the
+// first gallery image for galleries
+func coverImage(post *reddit.RedditPost) *notionapi.Image {
+	if post == nil {
+		return nil
+	}
+
+	coverURL := post.PreviewImageURL()
+	if coverURL == "" {
+		if images := post.GalleryImages(); len(images) > 0 {
+			coverURL = images[0].URL
+		}
+	}
+	if coverURL == "" {
+		return nil
+	}
+
+	return &notionapi.Image{
+		Type:     notionapi.FileTypeExternal,
+		External: &notionapi.FileObject{URL: coverURL},
+	}
+}
+
+// mediaHost returns a URL's host without "www." and known media subdomains
+// (i.imgur.com, m.youtube.com), or "" if it can't be parsed
+func mediaHost(rawURL string) string {
+	parsed, err := url.Parse(rawURL)
+	if err != nil {
+		return ""
+	}
+	host := strings.ToLower(parsed.Hostname())
+	for _, prefix := range []string{"www.", "m.", "i."} {
+		host = strings.TrimPrefix(host, prefix)
+	}
+	return host
+}
+
+// isImagePath reports whether a URL points straight at an image file
+func isImagePath(rawURL string) bool {
+	parsed, err := url.Parse(rawURL)
+	if err != nil {
+		return false
+	}
+	switch strings.ToLower(path.Ext(parsed.Path)) {
+	case ".jpg", ".jpeg", ".png", ".gif", ".webp":
+		return true
+	}
+	return false
+}
+
+// newImageBlock creates an image block for an external URL
+func newImageBlock(imageURL, caption string) notionapi.ImageBlock {
+	return notionapi.ImageBlock{
+		BasicBlock: notionapi.BasicBlock{
+			Object: notionapi.ObjectTypeBlock,
+			Type:   notionapi.BlockTypeImage,
+		},
+		Image: notionapi.Image{
+			Type:     notionapi.FileTypeExternal,
+			External: &notionapi.FileObject{URL: imageURL},
+			Caption:  captionText(caption),
+		},
+	}
+}
+
+// newVideoBlock creates a video block for an external URL
+func newVideoBlock(videoURL string) notionapi.VideoBlock {
+	return notionapi.VideoBlock{
+		BasicBlock: notionapi.BasicBlock{
+			Object: notionapi.ObjectTypeBlock,
+			Type:   notionapi.BlockTypeVideo,
+		},
+		Video: notionapi.Video{
+			Type:     notionapi.FileTypeExternal,
+			External: &notionapi.FileObject{URL: videoURL},
+		},
+	}
+}
+
+// newEmbedBlock creates an embed block for pages Notion can render inline
+func newEmbedBlock(embedURL string) notionapi.EmbedBlock {
+	return notionapi.EmbedBlock{
+		BasicBlock: notionapi.BasicBlock{
+			Object: notionapi.ObjectTypeBlock,
+			Type:   notionapi.BlockTypeEmbed,
+		},
+		Embed: notionapi.Embed{
+			URL: embedURL,
+		},
+	}
+}
+
+// captionText converts a caption to rich text, or nil when there is none
+func captionText(caption string) []notionapi.RichText {
+	if caption == "" {
+		return nil
+	}
+	return []notionapi.RichText{
+		{
+			Type: notionapi.ObjectTypeText,
+			Text: &notionapi.Text{Content: caption},
+		},
+	}
+}
@@ -0,0 +1,155 @@
+package notion
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"log"
+	"net/http"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/jomei/notionapi"
+)
+
+// DefaultSchemaTTL is how long a database schema is reused before it is
+// fetched again. Init reads it from NOTION_SCHEMA_TTL.
+var DefaultSchemaTTL = 5 * time.Minute
+
+// schemaKey identifies a database within a workspace
+type schemaKey struct {
+	workspace string
+	database  string
+}
+
+type cachedSchema struct {
+	properties notionapi.PropertyConfigs
+	lastEdited time.Time // The database's last_edited_time when it was fetched
+	fetchedAt  time.Time
+}
+
+// schemaCache holds database schemas shared by every client, so bulk saves
+// don't fetch the database before each page
+type schemaCache struct {
+	mu      sync.Mutex
+	entries map[schemaKey]cachedSchema
+}
+
+var schemas = &schemaCache{entries: make(map[schemaKey]cachedSchema)}
+
+// get returns a schema fetched less than DefaultSchemaTTL ago
+func (c *schemaCache) get(key schemaKey, now time.Time) (cachedSchema, bool) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	entry, ok := c.entries[key]
+	if !ok {
+		return cachedSchema{}, false
+	}
+	if now.Sub(entry.fetchedAt) > DefaultSchemaTTL {
+		delete(c.entries, key)
+		return cachedSchema{}, false
+	}
+	return entry, true
+}
+
+func (c *schemaCache) put(key schemaKey, entry cachedSchema) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	c.entries[key] = entry
+}
+
+func (c *schemaCache) invalidate(key schemaKey) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	delete(c.entries, key)
+}
+
+// observe drops a cached schema if the database has been edited since it was
+// fetched. Notion rounds last_edited_time to the minute, so edits in the same
+// minute as the fetch are only picked up once the TTL runs out.
+func (c *schemaCache) observe(key schemaKey, lastEdited time.Time) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	if entry, ok := c.entries[key]; ok && lastEdited.After(entry.lastEdited) {
+		log.Printf("[Notion] Database %s changed since its schema was cached, dropping it", key.database)
+		delete(c.entries, key)
+	}
+}
+
+// schemaKey returns the cache key of a database in the client's workspace.
+// IDs are compared without dashes since Notion accepts both forms.
+func (nc *NotionClient) schemaKey(databaseID string) schemaKey {
+	return schemaKey{
+		workspace: nc.workspace,
+		database:  strings.ReplaceAll(strings.ToLower(databaseID), "-", ""),
+	}
+}
+
+// GetDatabaseSchema returns a database's properties, reusing a cached copy
+// while it is fresh
+func (nc *NotionClient) GetDatabaseSchema(ctx context.Context, databaseID string) (notionapi.PropertyConfigs, error) {
+	if entry, ok := schemas.get(nc.schemaKey(databaseID), time.Now()); ok {
+		return entry.properties, nil
+	}
+	return nc.RefreshDatabaseSchema(ctx, databaseID)
+}
+
+// RefreshDatabaseSchema fetches a database's properties from Notion and
+// updates the cache
+func (nc *NotionClient) RefreshDatabaseSchema(ctx context.Context, databaseID string) (notionapi.PropertyConfigs, error) {
+	ctx, cancel := nc.readContext(ctx)
+	defer cancel()
+
+	database, err := nc.client.Database.Get(ctx, notionapi.DatabaseID(databaseID))
+	if err != nil {
+		log.Printf("[Notion] Error fetching database schema: %v", err)
+		return nil, fmt.Errorf("failed to fetch database: %w", err)
+	}
+
+	schemas.put(nc.schemaKey(databaseID), cachedSchema{
+		properties: database.Properties,
+		lastEdited: database.LastEditedTime,
+		fetchedAt:  time.Now(),
+	})
+	return database.Properties, nil
+}
+
+// InvalidateDatabaseSchema drops a database's cached schema so the next use
+// fetches it again
+func (nc *NotionClient) InvalidateDatabaseSchema(databaseID string) {
+	schemas.invalidate(nc.schemaKey(databaseID))
+}
+
+// ValidateDatabaseMapping checks a mapping against a database's schema and
+// returns the schema. A mapping that doesn't fit the cached schema is checked
+// again against a fresh one, since the database may have been changed to fit
+// it. On a *MappingError the schema is still returned.
+func (nc *NotionClient) ValidateDatabaseMapping(ctx context.Context, databaseID string, mapping PropertyMapping) (notionapi.PropertyConfigs, error) {
+	_, cached := schemas.get(nc.schemaKey(databaseID), time.Now())
+
+	schema, err := nc.GetDatabaseSchema(ctx, databaseID)
+	if err != nil {
+		return nil, err
+	}
+	err = ValidateMapping(schema, mapping)
+	if err == nil || !cached {
+		return schema, err
+	}
+
+	log.Printf("[Notion] Mapping doesn't fit the cached schema of %s, refetching it", databaseID)
+	if schema, err = nc.RefreshDatabaseSchema(ctx, databaseID); err != nil {
+		return nil, err
+	}
+	return schema, ValidateMapping(schema, mapping)
+}
+
+// isSchemaError reports whether Notion rejected a request because the
+// properties sent don't match the database, e.g. a property was renamed or
+// deleted after its schema was cached
+func isSchemaError(err error) bool {
+	var apiErr *notionapi.Error
+	return errors.As(err, &apiErr) && apiErr.Status == http.StatusBadRequest && apiErr.Code == "validation_error"
+}
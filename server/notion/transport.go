@@ -0,0 +1,223 @@
+package notion
+
+import (
+	"context"
+	"crypto/sha256"
+	"encoding/hex"
+	"net/http"
+	"strings"
+	"sync"
+	"time"
+
+	"re2no/internal/httpretry"
+)
+
+// Notion allows an average of three requests per second per integration
+// (i.e. per workspace token) and asks clients to back off on 429s.
+const (
+	requestsPerSecond = 3
+	defaultMaxRetries = 3
+	defaultBaseDelay  = 500 * time.Millisecond
+	defaultMaxDelay   = 8 * time.Second
+)
+
+// Transport is an http.RoundTripper that paces a workspace's requests and
+// retries them with exponential backoff and jitter, honoring Retry-After.
+// Rate limited (429) requests and ones that never reached Notion are always
+// retried; conflicts (409), server errors (5xx) and dropped connections only
+// when repeating the request can't apply it twice (see idempotent).
+type Transport struct {
+	Base       http.RoundTripper
+	MaxRetries int
+	BaseDelay  time.Duration
+	MaxDelay   time.Duration
+
+	workspace string
+}
+
+// limiterIdleTTL is how long a workspace's rate limiter is kept after its
+// last request. Later requests start a fresh one, which is equivalent since an
+// idle limiter has no backlog.
+const limiterIdleTTL = 10 * time.Minute
+
+// limiterRegistry holds one rate limiter per workspace token, shared by every
+// client and request for that workspace
+type limiterRegistry struct {
+	mu      sync.Mutex
+	entries map[string]*rateLimiter
+	swept   time.Time
+}
+
+var limiters = &limiterRegistry{entries: make(map[string]*rateLimiter)}
+
+// get returns a workspace's limiter, creating it if needed, and drops the
+// limiters of workspaces that have gone idle (e.g. disconnected or
+// reauthorized with a new token)
+func (r *limiterRegistry) get(workspace string) *rateLimiter {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	now := time.Now()
+	if now.Sub(r.swept) > limiterIdleTTL {
+		for key, limiter := range r.entries {
+			if limiter.idleSince(now) > limiterIdleTTL {
+				delete(r.entries, key)
+			}
+		}
+		r.swept = now
+	}
+
+	limiter, ok := r.entries[workspace]
+	if !ok {
+		limiter = newRateLimiter(requestsPerSecond)
+		r.entries[workspace] = limiter
+	}
+	return limiter
+}
+
+// workspaceKey identifies the workspace an access token belongs to (each
+// token is issued to one workspace) without keeping the token itself
+func workspaceKey(accessToken string) string {
+	sum := sha256.Sum256([]byte(accessToken))
+	return hex.EncodeToString(sum[:])
+}
+
+// newTransport returns a transport drawing from a workspace's rate limit
+func newTransport(workspace string) *Transport {
+	return &Transport{
+		Base:       http.DefaultTransport,
+		MaxRetries: defaultMaxRetries,
+		BaseDelay:  defaultBaseDelay,
+		MaxDelay:   defaultMaxDelay,
+		workspace:  workspace,
+	}
+}
+
+// RoundTrip implements http.RoundTripper
+func (t *Transport) RoundTrip(req *http.Request) (*http.Response, error) {
+	ctx := req.Context()
+	repeatable := idempotent(req)
+	limiter := limiters.get(t.workspace)
+
+	for attempt := 0; ; attempt++ {
+		if err := limiter.wait(ctx); err != nil {
+			return nil, err
+		}
+
+		attemptReq := req
+		if attempt > 0 {
+			var err error
+			if attemptReq, err = httpretry.Rewind(req); err != nil {
+				return nil, err
+			}
+		}
+
+		resp, err := t.Base.RoundTrip(attemptReq)
+		if err != nil {
+			if attempt >= t.MaxRetries || ctx.Err() != nil || !(repeatable || httpretry.Unsent(err)) {
+				return nil, err
+			}
+			if err := httpretry.Sleep(ctx, t.backoff(attempt)); err != nil {
+				return nil, err
+			}
+			continue
+		}
+
+		if !retryable(resp.StatusCode, repeatable) || attempt >= t.MaxRetries {
+			return resp, nil
+		}
+
+		delay := t.backoff(attempt)
+		if retryAfter, ok := httpretry.RetryAfter(resp.Header, "Retry-After"); ok {
+			delay = retryAfter
+		}
+		if resp.StatusCode == http.StatusTooManyRequests {
+			// The whole workspace is over its limit, not just this request
+			limiter.pause(delay)
+		}
+
+		resp.Body.Close()
+		if err := httpretry.Sleep(ctx, delay); err != nil {
+			return nil, err
+		}
+	}
+}
+
+// retryable reports whether a request that got status may succeed if
+// repeated. Notion rejects rate limited requests before acting on them, so
+// those are always safe to send again.
+func retryable(status int, idempotent bool) bool {
+	switch status {
+	case http.StatusTooManyRequests:
+		return true
+	case http.StatusConflict,
+		http.StatusInternalServerError, http.StatusBadGateway,
+		http.StatusServiceUnavailable, http.StatusGatewayTimeout:
+		return idempotent
+	}
+	return false
+}
+
+// idempotent reports whether sending req twice has the same effect as once:
+// reads and updates do, creating pages or databases doesn't, and neither does
+// appending blocks, which Notion takes as a PATCH
+func idempotent(req *http.Request) bool {
+	switch req.Method {
+	case http.MethodGet, http.MethodHead:
+		return true
+	case http.MethodPatch:
+		return !strings.HasSuffix(req.URL.Path, "/children")
+	}
+	return false
+}
+
+// backoff returns the delay before retry attempt+1
+func (t *Transport) backoff(attempt int) time.Duration {
+	return httpretry.Backoff(attempt, t.BaseDelay, t.MaxDelay)
+}
+
+// rateLimiter spaces requests evenly: each caller reserves the next free
+// slot and waits for it
+type rateLimiter struct {
+	mu       sync.Mutex
+	next     time.Time
+	interval time.Duration
+}
+
+func newRateLimiter(perSecond int) *rateLimiter {
+	return &rateLimiter{next: time.Now(), interval: time.Second / time.Duration(perSecond)}
+}
+
+// wait blocks until the caller's slot comes up or ctx is done
+func (l *rateLimiter) wait(ctx context.Context) error {
+	l.mu.Lock()
+	now := time.Now()
+	slot := l.next
+	if slot.Before(now) {
+		slot = now
+	}
+	l.next = slot.Add(l.interval)
+	l.mu.Unlock()
+
+	if delay := slot.Sub(now); delay > 0 {
+		return httpretry.Sleep(ctx, delay)
+	}
+	return nil
+}
+
+// pause holds back every request that hasn't been scheduled yet for d
+func (l *rateLimiter) pause(d time.Duration) {
+	l.mu.Lock()
+	defer l.mu.Unlock()
+
+	if resume := time.Now().Add(d); resume.After(l.next) {
+		l.next = resume
+	}
+}
+
+// idleSince returns how long ago the limiter's last scheduled request ran
+func (l *rateLimiter) idleSince(now time.Time) time.Duration {
+	l.mu.Lock()
+	defer l.mu.Unlock()
+	return now.Sub(l.next)
+}
@@ -0,0 +1,208 @@
+package reddit
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"net/http"
+	"net/url"
+	"os"
+	"time"
+
+	"golang.org/x/oauth2"
+)
+
+const authorizeURL = "https://www.reddit.com/api/v1/authorize"
+
+// accountScopes lets Re2no read the user's identity and saved items
+var accountScopes = []string{"identity", "history", "read"}
+
+// ErrAccountLinkingDisabled is returned when Reddit app credentials aren't configured
+var ErrAccountLinkingDisabled = errors.New("reddit account linking is not configured")
+
+// accountConfig is the user OAuth (authorization code) config, set by Init
+// when app credentials are available
+var accountConfig *oauth2.Config
+
+// initAccountOAuth configures user OAuth; called from Init once credentials are known.
+// REDDIT_REDIRECT_URI must match the redirect URI registered for the Reddit app.
+func initAccountOAuth(clientID, clientSecret string) {
+	redirectURI := os.Getenv("REDDIT_REDIRECT_URI")
+	if redirectURI == "" {
+		redirectURI = "http://localhost:3000/dashboard"
+	}
+
+	accountConfig = &oauth2.Config{
+		ClientID:     clientID,
+		ClientSecret: clientSecret,
+		RedirectURL:  redirectURI,
+		Scopes:       accountScopes,
+		Endpoint: oauth2.Endpoint{
+			AuthURL:   authorizeURL,
+			TokenURL:  tokenURL,
+			AuthStyle: oauth2.AuthStyleInHeader,
+		},
+	}
+}
+
+// AccountAuthURL returns the Reddit consent URL for linking a user's account.
+// duration=permanent asks Reddit for a refresh token.
+func AccountAuthURL(state string) (string, error) {
+	if accountConfig == nil {
+		return "", ErrAccountLinkingDisabled
+	}
+	return accountConfig.AuthCodeURL(state, oauth2.SetAuthURLParam("duration", "permanent")), nil
+}
+
+// ExchangeAccountCode trades the authorization code from the consent redirect for tokens
+func ExchangeAccountCode(ctx context.Context, code string) (*oauth2.Token, error) {
+	if accountConfig == nil {
+		return nil, ErrAccountLinkingDisabled
+	}
+	return accountConfig.Exchange(tokenContext(ctx), code)
+}
+
+// AccountTokenSource returns a token source for a linked account that refreshes
+// the access token when it expires. Compare the returned tokens with the stored
+// one to persist refreshes. Refreshes are made with ctx, so the source
+// shouldn't outlive it.
+func AccountTokenSource(ctx context.Context, token *oauth2.Token) (oauth2.TokenSource, error) {
+	if accountConfig == nil {
+		return nil, ErrAccountLinkingDisabled
+	}
+	return accountConfig.TokenSource(tokenContext(ctx), token), nil
+}
+
+// tokenContext makes token requests go out with the app's User-Agent
+func tokenContext(ctx context.Context) context.Context {
+	httpClient := &http.Client{
+		Timeout:   10 * time.Second,
+		Transport: userAgentTransport{},
+	}
+	return context.WithValue(ctx, oauth2.HTTPClient, httpClient)
+}
+
+// Identity is the Reddit account a token belongs to
+type Identity struct {
+	ID   string `json:"id"`
+	Name string `json:"name"`
+}
+
+// SavedComment is a comment from a user's saved list
+type SavedComment struct {
+	ID         string   `json:"id"`
+	Name       string   `json:"name"` // Fullname, e.g. t1_abc123
+	Author     string   `json:"author"`
+	Body       string   `json:"body"`
+	Score      int      `json:"score"`
+	Subreddit  string   `json:"subreddit"`
+	Permalink  string   `json:"permalink"`
+	LinkID     string   `json:"link_id"`
+	LinkTitle  string   `json:"link_title"`
+	LinkURL    string   `json:"link_url"`
+	CreatedUTC UnixTime `json:"created_utc"`
+}
+
+// SavedItem is one entry of a saved list: either a post (t3) or a comment (t1)
+type SavedItem struct {
+	Kind    string        `json:"kind"`
+	Post    *RedditPost   `json:"post,omitempty"`
+	Comment *SavedComment `json:"comment,omitempty"`
+}
+
+// SavedPage is one page of a user's saved items
+type SavedPage struct {
+	Items []SavedItem `json:"items"`
+	After string      `json:"after"` // Empty on the last page
+}
+
+// FetchSavedParams holds the parameters for paging through saved items
+type FetchSavedParams struct {
+	Username string
+	After    string
+	Limit    int
+}
+
+// FetchIdentity returns the account a user token belongs to
+func (c *RedditClient) FetchIdentity(ctx context.Context, source oauth2.TokenSource) (*Identity, error) {
+	body, err := c.getAsUser(ctx, "/api/v1/me", nil, source)
+	if err != nil {
+		return nil, fmt.Errorf("failed to fetch identity: %w", err)
+	}
+
+	var identity Identity
+	if err := json.Unmarshal(body, &identity); err != nil {
+		return nil, fmt.Errorf("failed to parse identity: %w", err)
+	}
+	return &identity, nil
+}
+
+// FetchSaved fetches one page of /user/{name}/saved with the user's own token
+func (c *RedditClient) FetchSaved(ctx context.Context, source oauth2.TokenSource, params FetchSavedParams) (*SavedPage, error) {
+	if params.Username == "" {
+		return nil, fmt.Errorf("username is required")
+	}
+	if params.Limit <= 0 || params.Limit > 100 {
+		params.Limit = 100
+	}
+
+	urlParams := url.Values{}
+	urlParams.Add("limit", fmt.Sprintf("%d", params.Limit))
+	if params.After != "" {
+		urlParams.Add("after", params.After)
+	}
+
+	body, err := c.getAsUser(ctx, fmt.Sprintf("/user/%s/saved", url.PathEscape(params.Username)), urlParams, source)
+	if err != nil {
+		return nil, fmt.Errorf("failed to fetch saved items: %w", err)
+	}
+
+	var listing struct {
+		Data struct {
+			Children []listingThing `json:"children"`
+			After    string         `json:"after"`
+		} `json:"data"`
+	}
+	if err := json.Unmarshal(body, &listing); err != nil {
+		return nil, fmt.Errorf("failed to parse response: %w", err)
+	}
+
+	page := &SavedPage{
+		Items: make([]SavedItem, 0, len(listing.Data.Children)),
+		After: listing.Data.After,
+	}
+	for _, child := range listing.Data.Children {
+		switch child.Kind {
+		case "t3":
+			var post RedditPost
+			if err := json.Unmarshal(child.Data, &post); err != nil {
+				return nil, fmt.Errorf("failed to parse saved post: %w", err)
+			}
+			page.Items = append(page.Items, SavedItem{Kind: child.Kind, Post: &post})
+		case "t1":
+			var comment SavedComment
+			if err := json.Unmarshal(child.Data, &comment); err != nil {
+				return nil, fmt.Errorf("failed to parse saved comment: %w", err)
+			}
+			page.Items = append(page.Items, SavedItem{Kind: child.Kind, Comment: &comment})
+		}
+	}
+
+	return page, nil
+}
+
+// getAsUser fetches an oauth.reddit.com path with a linked account's token
+func (c *RedditClient) getAsUser(ctx context.Context, path string, params url.Values, source oauth2.TokenSource) ([]byte, error) {
+	ctx, cancel := withTimeout(ctx, c.Timeouts.Account, DefaultTimeouts.Account)
+	defer cancel()
+
+	body, status, err := c.request(ctx, path, params, source)
+	if err != nil {
+		return nil, err
+	}
+	if status != http.StatusOK {
+		return nil, fmt.Errorf("reddit API returned status %d: %s", status, string(body))
+	}
+	return body, nil
+}
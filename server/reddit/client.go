@@ -1,44 +1,95 @@
 package reddit
 
 import (
-"encoding/json"
-"fmt"
-"io"
-"net/http"
-"net/url"
-"time"
+	"context"
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"net/url"
+	"strings"
+	"time"
 )
 
 // RedditClient handles Reddit API requests
 type RedditClient struct {
 	HTTPClient *http.Client
-	UserAgent  string
+	UserAgent  string   // Defaults to REDDIT_USER_AGENT when empty
+	Timeouts   Timeouts // Zero fields default to DefaultTimeouts
+}
+
+// Timeouts bounds each kind of Reddit operation. A timeout covers rate limit
+// pacing and retries, not just a single request, and applies on top of any
+// deadline already on the caller's context.
+type Timeouts struct {
+	Listing  time.Duration // One page of a subreddit listing or search
+	Comments time.Duration // A post's comment tree
+	Account  time.Duration // Identity and saved items of a linked account
+}
+
+// DefaultTimeouts apply to clients that don't set their own; Init overrides
+// them from REDDIT_LISTING_TIMEOUT, REDDIT_COMMENTS_TIMEOUT and REDDIT_ACCOUNT_TIMEOUT
+var DefaultTimeouts = Timeouts{
+	Listing:  15 * time.Second,
+	Comments: 20 * time.Second,
+	Account:  20 * time.Second,
 }
 
 // NewRedditClient creates a new Reddit API client
 func NewRedditClient() *RedditClient {
 	return &RedditClient{
+		// Requests are bounded by their context (see Timeouts) rather than a client timeout
 		HTTPClient: &http.Client{
-			Timeout: 10 * time.Second,
+			Transport: sharedTransport,
 		},
-		UserAgent: "Re2no:v1.0.0 (by /u/your_username)",
 	}
 }
 
+// withTimeout derives the context for one operation, using fallback when
+// the client doesn't set timeout
+func withTimeout(ctx context.Context, timeout, fallback time.Duration) (context.Context, context.CancelFunc) {
+	if timeout <= 0 {
+		timeout = fallback
+	}
+	return context.WithTimeout(ctx, timeout)
+}
+
 // RedditPost represents a single Reddit post
 type RedditPost struct {
-	ID          string  `json:"id"`
-	Title       string  `json:"title"`
-	Author      string  `json:"author"`
-	Subreddit   string  `json:"subreddit"`
-	Score       int     `json:"score"`
-	URL         string  `json:"url"`
-	Permalink   string  `json:"permalink"`
-	CreatedUTC  float64 `json:"created_utc"`
-	NumComments int     `json:"num_comments"`
-	Thumbnail   string  `json:"thumbnail"`
-	SelfText    string  `json:"selftext"`
-	IsVideo     bool    `json:"is_video"`
+	ID          string   `json:"id"`
+	Name        string   `json:"name"` // Fullname, e.g. t3_abc123
+	Title       string   `json:"title"`
+	Author      string   `json:"author"`
+	AuthorFlair string   `json:"author_flair_text,omitempty"`
+	Subreddit   string   `json:"subreddit"`
+	Score       int      `json:"score"`
+	UpvoteRatio float64  `json:"upvote_ratio"`
+	URL         string   `json:"url"`
+	Permalink   string   `json:"permalink"`
+	CreatedUTC  UnixTime `json:"created_utc"`
+	NumComments int      `json:"num_comments"`
+	Thumbnail   string   `json:"thumbnail"`
+	SelfText    string   `json:"selftext"`
+	IsVideo     bool     `json:"is_video"`
+	IsSelf      bool     `json:"is_self"`
+	IsGallery   bool     `json:"is_gallery"`
+	Over18      bool     `json:"over_18"`
+	Spoiler     bool     `json:"spoiler"`
+	Stickied    bool     `json:"stickied"`
+	Domain      string   `json:"domain"`
+	PostHint    string   `json:"post_hint,omitempty"`
+	LinkFlair   string   `json:"link_flair_text"`
+
+	// Media: preview renditions for link and image posts, the items of a
+	// gallery post, and Reddit-hosted video or embeds
+	Preview       *Preview                 `json:"preview,omitempty"`
+	GalleryData   *GalleryData             `json:"gallery_data,omitempty"`
+	MediaMetadata map[string]MediaMetadata `json:"media_metadata,omitempty"`
+	SecureMedia   *Media                   `json:"secure_media,omitempty"`
+
+	// CrosspostParent is the fullname of the original post when this is a
+	// crosspost; CrosspostParentList holds the original post itself
+	CrosspostParent     string       `json:"crosspost_parent,omitempty"`
+	CrosspostParentList []RedditPost `json:"crosspost_parent_list,omitempty"`
 }
 
 // RedditResponse represents the Reddit API response structure
@@ -62,7 +113,17 @@ type FetchPostsParams struct {
 }
 
 // FetchPosts fetches posts from Reddit based on the given parameters
-func (c *RedditClient) FetchPosts(params FetchPostsParams) ([]RedditPost, error) {
+func (c *RedditClient) FetchPosts(ctx context.Context, params FetchPostsParams) ([]RedditPost, error) {
+	posts, _, err := c.fetchPostsPage(ctx, params)
+	return posts, err
+}
+
+// fetchPostsPage fetches one page of a subreddit listing along with the
+// "after" token for the next page (empty on the last page)
+func (c *RedditClient) fetchPostsPage(ctx context.Context, params FetchPostsParams) ([]RedditPost, string, error) {
+	ctx, cancel := withTimeout(ctx, c.Timeouts.Listing, DefaultTimeouts.Listing)
+	defer cancel()
+
 	if params.Subreddit == "" {
 		params.Subreddit = "all"
 	}
@@ -73,105 +134,102 @@ func (c *RedditClient) FetchPosts(params FetchPostsParams) ([]RedditPost, error)
 		params.Limit = 25
 	}
 
-	baseURL := fmt.Sprintf("https://www.reddit.com/r/%s/%s.json", params.Subreddit, params.Sort)
-	
 	urlParams := url.Values{}
 	urlParams.Add("limit", fmt.Sprintf("%d", params.Limit))
-	
+
 	if params.TimeRange != "" && (params.Sort == "top" || params.Sort == "controversial") {
 		urlParams.Add("t", params.TimeRange)
 	}
-	
+
 	if params.After != "" {
 		urlParams.Add("after", params.After)
 	}
 
-	fullURL := baseURL + "?" + urlParams.Encode()
-
-	req, err := http.NewRequest("GET", fullURL, nil)
-	if err != nil {
-		return nil, fmt.Errorf("failed to create request: %w", err)
-	}
-
-	req.Header.Set("User-Agent", c.UserAgent)
-
-	resp, err := c.HTTPClient.Do(req)
-	if err != nil {
-		return nil, fmt.Errorf("failed to fetch posts: %w", err)
-	}
-	defer resp.Body.Close()
-
-	if resp.StatusCode != http.StatusOK {
-		body, _ := io.ReadAll(resp.Body)
-		return nil, fmt.Errorf("reddit API returned status %d: %s", resp.StatusCode, string(body))
-	}
-
-	body, err := io.ReadAll(resp.Body)
+	body, err := c.get(ctx, fmt.Sprintf("/r/%s/%s", params.Subreddit, params.Sort), urlParams)
 	if err != nil {
-		return nil, fmt.Errorf("failed to read response: %w", err)
+		return nil, "", fmt.Errorf("failed to fetch posts: %w", err)
 	}
 
-	var redditResp RedditResponse
-	if err := json.Unmarshal(body, &redditResp); err != nil {
-		return nil, fmt.Errorf("failed to parse response: %w", err)
-	}
-
-	posts := make([]RedditPost, 0, len(redditResp.Data.Children))
-	for _, child := range redditResp.Data.Children {
-		posts = append(posts, child.Data)
-	}
+	return parseListing(body)
+}
 
-	return posts, nil
+// SearchPostsParams holds the parameters for searching a subreddit
+type SearchPostsParams struct {
+	Subreddit string
+	Keyword   string
+	Sort      string
+	Limit     int
+	After     string
 }
 
 // SearchPosts searches for posts containing a keyword
-func (c *RedditClient) SearchPosts(subreddit, keyword string, sort string, limit int) ([]RedditPost, error) {
-	if subreddit == "" {
-		subreddit = "all"
+func (c *RedditClient) SearchPosts(ctx context.Context, params SearchPostsParams) ([]RedditPost, error) {
+	posts, _, err := c.searchPostsPage(ctx, params)
+	return posts, err
+}
+
+// searchPostsPage fetches one page of search results along with the "after"
+// token for the next page (empty on the last page)
+func (c *RedditClient) searchPostsPage(ctx context.Context, params SearchPostsParams) ([]RedditPost, string, error) {
+	ctx, cancel := withTimeout(ctx, c.Timeouts.Listing, DefaultTimeouts.Listing)
+	defer cancel()
+
+	if params.Subreddit == "" {
+		params.Subreddit = "all"
 	}
-	if sort == "" {
-		sort = "relevance"
+	if params.Sort == "" {
+		params.Sort = "relevance"
 	}
-	if limit <= 0 || limit > 100 {
-		limit = 25
+	if params.Limit <= 0 || params.Limit > 100 {
+		params.Limit = 25
 	}
 
-	baseURL := fmt.Sprintf("https://www.reddit.com/r/%s/search.json", subreddit)
-	
 	urlParams := url.Values{}
-	urlParams.Add("q", keyword)
+	urlParams.Add("q", params.Keyword)
 	urlParams.Add("restrict_sr", "true")
-	urlParams.Add("sort", sort)
-	urlParams.Add("limit", fmt.Sprintf("%d", limit))
+	urlParams.Add("sort", params.Sort)
+	urlParams.Add("limit", fmt.Sprintf("%d", params.Limit))
 
-	fullURL := baseURL + "?" + urlParams.Encode()
+	if params.After != "" {
+		urlParams.Add("after", params.After)
+	}
 
-	req, err := http.NewRequest("GET", fullURL, nil)
+	body, err := c.get(ctx, fmt.Sprintf("/r/%s/search", params.Subreddit), urlParams)
 	if err != nil {
-		return nil, fmt.Errorf("failed to create request: %w", err)
+		return nil, "", fmt.Errorf("failed to search posts: %w", err)
 	}
 
-	req.Header.Set("User-Agent", c.UserAgent)
+	return parseListing(body)
+}
 
-	resp, err := c.HTTPClient.Do(req)
-	if err != nil {
-		return nil, fmt.Errorf("failed to search posts: %w", err)
-	}
-	defer resp.Body.Close()
+// FetchPost fetches a single post by ID (with or without the t3_ prefix)
+func (c *RedditClient) FetchPost(ctx context.Context, postID string) (*RedditPost, error) {
+	ctx, cancel := withTimeout(ctx, c.Timeouts.Listing, DefaultTimeouts.Listing)
+	defer cancel()
+
+	urlParams := url.Values{}
+	urlParams.Add("id", "t3_"+strings.TrimPrefix(postID, "t3_"))
 
-	if resp.StatusCode != http.StatusOK {
-		body, _ := io.ReadAll(resp.Body)
-		return nil, fmt.Errorf("reddit API returned status %d: %s", resp.StatusCode, string(body))
+	body, err := c.get(ctx, "/api/info", urlParams)
+	if err != nil {
+		return nil, fmt.Errorf("failed to fetch post: %w", err)
 	}
 
-	body, err := io.ReadAll(resp.Body)
+	posts, _, err := parseListing(body)
 	if err != nil {
-		return nil, fmt.Errorf("failed to read response: %w", err)
+		return nil, err
+	}
+	if len(posts) == 0 {
+		return nil, fmt.Errorf("post %s not found", postID)
 	}
+	return &posts[0], nil
+}
 
+// parseListing decodes a listing response into its posts and "after" token
+func parseListing(body []byte) ([]RedditPost, string, error) {
 	var redditResp RedditResponse
 	if err := json.Unmarshal(body, &redditResp); err != nil {
-		return nil, fmt.Errorf("failed to parse response: %w", err)
+		return nil, "", fmt.Errorf("failed to parse response: %w", err)
 	}
 
 	posts := make([]RedditPost, 0, len(redditResp.Data.Children))
@@ -179,5 +237,5 @@ func (c *RedditClient) SearchPosts(subreddit, keyword string, sort string, limit
 		posts = append(posts, child.Data)
 	}
 
-	return posts, nil
+	return posts, redditResp.Data.After, nil
 }
@@ -0,0 +1,163 @@
+package reddit
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"net/url"
+)
+
+// Comment represents a single Reddit comment with its nested replies
+type Comment struct {
+	ID         string    `json:"id"`
+	Author     string    `json:"author"`
+	Body       string    `json:"body"`
+	Score      int       `json:"score"`
+	Permalink  string    `json:"permalink"`
+	CreatedUTC UnixTime  `json:"created_utc"`
+	Depth      int       `json:"depth"`
+	Replies    []Comment `json:"replies"`
+	// MoreReplies counts replies Reddit collapsed behind a "load more" stub
+	MoreReplies int `json:"more_replies"`
+}
+
+// listingThing is a single child of a Reddit listing (kind t1, t3 or more)
+type listingThing struct {
+	Kind string          `json:"kind"`
+	Data json.RawMessage `json:"data"`
+}
+
+// commentListing is the listing shape used for comments and their replies
+type commentListing struct {
+	Data struct {
+		Children []listingThing `json:"children"`
+	} `json:"data"`
+}
+
+// rawComment mirrors the t1 payload; replies is either "" or a listing
+type rawComment struct {
+	ID         string          `json:"id"`
+	Author     string          `json:"author"`
+	Body       string          `json:"body"`
+	Score      int             `json:"score"`
+	Permalink  string          `json:"permalink"`
+	CreatedUTC UnixTime        `json:"created_utc"`
+	Depth      int             `json:"depth"`
+	Replies    json.RawMessage `json:"replies"`
+}
+
+// moreStub mirrors the "more" payload Reddit uses for collapsed replies
+type moreStub struct {
+	Count    int      `json:"count"`
+	Children []string `json:"children"`
+}
+
+// FetchCommentsParams holds the parameters for fetching a comment thread
+type FetchCommentsParams struct {
+	PostID string
+	Sort   string // confidence, top, new, controversial, old, qa
+	Depth  int
+	Limit  int
+}
+
+// FetchComments fetches the comment tree of a post
+func (c *RedditClient) FetchComments(ctx context.Context, params FetchCommentsParams) ([]Comment, error) {
+	if params.PostID == "" {
+		return nil, fmt.Errorf("post ID is required")
+	}
+	if params.Sort == "" {
+		params.Sort = "confidence"
+	}
+	if params.Depth <= 0 || params.Depth > 10 {
+		params.Depth = 3
+	}
+	if params.Limit <= 0 || params.Limit > 500 {
+		params.Limit = 50
+	}
+
+	urlParams := url.Values{}
+	urlParams.Add("sort", params.Sort)
+	urlParams.Add("depth", fmt.Sprintf("%d", params.Depth))
+	urlParams.Add("limit", fmt.Sprintf("%d", params.Limit))
+
+	ctx, cancel := withTimeout(ctx, c.Timeouts.Comments, DefaultTimeouts.Comments)
+	defer cancel()
+
+	body, err := c.get(ctx, "/comments/"+params.PostID, urlParams)
+	if err != nil {
+		return nil, fmt.Errorf("failed to fetch comments: %w", err)
+	}
+
+	// The response is a pair of listings: the post itself, then its comments
+	var listings []commentListing
+	if err := json.Unmarshal(body, &listings); err != nil {
+		return nil, fmt.Errorf("failed to parse response: %w", err)
+	}
+	if len(listings) < 2 {
+		return []Comment{}, nil
+	}
+
+	comments, _, err := parseCommentChildren(listings[1].Data.Children)
+	if err != nil {
+		return nil, fmt.Errorf("failed to parse comments: %w", err)
+	}
+
+	return comments, nil
+}
+
+// parseCommentChildren converts listing children into a comment tree.
+// It returns the parsed comments and the number of replies hidden behind "more" stubs.
+func parseCommentChildren(children []listingThing) ([]Comment, int, error) {
+	comments := make([]Comment, 0, len(children))
+	more := 0
+
+	for _, child := range children {
+		switch child.Kind {
+		case "t1":
+			var raw rawComment
+			if err := json.Unmarshal(child.Data, &raw); err != nil {
+				return nil, 0, err
+			}
+
+			comment := Comment{
+				ID:         raw.ID,
+				Author:     raw.Author,
+				Body:       raw.Body,
+				Score:      raw.Score,
+				Permalink:  raw.Permalink,
+				CreatedUTC: raw.CreatedUTC,
+				Depth:      raw.Depth,
+				Replies:    []Comment{},
+			}
+
+			// Replies is an empty string when there are none
+			if len(raw.Replies) > 0 && raw.Replies[0] == '{' {
+				var replies commentListing
+				if err := json.Unmarshal(raw.Replies, &replies); err != nil {
+					return nil, 0, err
+				}
+				parsed, hidden, err := parseCommentChildren(replies.Data.Children)
+				if err != nil {
+					return nil, 0, err
+				}
+				comment.Replies = parsed
+				comment.MoreReplies = hidden
+			}
+
+			comments = append(comments, comment)
+
+		case "more":
+			var stub moreStub
+			if err := json.Unmarshal(child.Data, &stub); err != nil {
+				return nil, 0, err
+			}
+			if stub.Count > 0 {
+				more += stub.Count
+			} else {
+				more += len(stub.Children)
+			}
+		}
+	}
+
+	return comments, more, nil
+}
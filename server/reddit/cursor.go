@@ -0,0 +1,73 @@
+package reddit
+
+import (
+	"crypto/sha256"
+	"encoding/base64"
+	"encoding/hex"
+	"encoding/json"
+	"errors"
+	"sort"
+	"strings"
+)
+
+// ErrInvalidCursor is returned for a cursor that can't be decoded or that
+// belongs to a different query
+var ErrInvalidCursor = errors.New("invalid cursor")
+
+// FeedCursor records where each subreddit of a merged feed left off. It is
+// handed to clients as an opaque string and only valid for the query it was
+// issued for.
+type FeedCursor struct {
+	Query string            `json:"q"`
+	After map[string]string `json:"a,omitempty"` // Per-subreddit listing or search "after" token
+	Done  []string          `json:"d,omitempty"` // Subreddits with no more pages
+}
+
+// isDone reports whether a subreddit has been read to the end
+func (cur *FeedCursor) isDone(subreddit string) bool {
+	for _, done := range cur.Done {
+		if done == subreddit {
+			return true
+		}
+	}
+	return false
+}
+
+// Encode returns the cursor as an opaque URL-safe string
+func (cur *FeedCursor) Encode() string {
+	data, _ := json.Marshal(cur)
+	return base64.RawURLEncoding.EncodeToString(data)
+}
+
+// DecodeCursor parses a cursor from Encode and checks it was issued for params
+func DecodeCursor(encoded string, params MultiFetchParams) (*FeedCursor, error) {
+	data, err := base64.RawURLEncoding.DecodeString(encoded)
+	if err != nil {
+		return nil, ErrInvalidCursor
+	}
+
+	var cur FeedCursor
+	if err := json.Unmarshal(data, &cur); err != nil {
+		return nil, ErrInvalidCursor
+	}
+	if cur.Query != queryFingerprint(params) {
+		return nil, ErrInvalidCursor
+	}
+	return &cur, nil
+}
+
+// queryFingerprint identifies the query a cursor pages through, so a cursor
+// can't be replayed against different subreddits, keyword, sort or filter
+func queryFingerprint(params MultiFetchParams) string {
+	subreddits := normalizeSubreddits(params.Subreddits)
+	sort.Strings(subreddits)
+
+	sum := sha256.Sum256([]byte(strings.Join([]string{
+		strings.Join(subreddits, ","),
+		params.Keyword,
+		params.Sort,
+		params.TimeRange,
+		params.Filter.key(),
+	}, "\n")))
+	return hex.EncodeToString(sum[:8])
+}
@@ -0,0 +1,44 @@
+package reddit
+
+import (
+	"errors"
+	"reflect"
+	"testing"
+)
+
+func TestNormalizeSubreddits(t *testing.T) {
+	got := normalizeSubreddits([]string{"GoLang", "rust", "golang", "Rust", "programming"})
+	want := []string{"golang", "rust", "programming"}
+	if !reflect.DeepEqual(got, want) {
+		t.Errorf("normalizeSubreddits() = %q, want %q", got, want)
+	}
+}
+
+func TestDecodeCursor(t *testing.T) {
+	params := MultiFetchParams{Subreddits: []string{"GoLang", "rust"}, Sort: "new"}
+	cursor := &FeedCursor{Query: queryFingerprint(params), After: map[string]string{"golang": "t3_abc"}}
+	encoded := cursor.Encode()
+
+	// The same query however the names are cased or ordered
+	same := MultiFetchParams{Subreddits: []string{"Rust", "golang"}, Sort: "new"}
+	decoded, err := DecodeCursor(encoded, same)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if decoded.After["golang"] != "t3_abc" {
+		t.Errorf("decoded cursor = %+v", decoded)
+	}
+
+	for name, other := range map[string]MultiFetchParams{
+		"other subreddits": {Subreddits: []string{"golang"}, Sort: "new"},
+		"other sort":       {Subreddits: []string{"golang", "rust"}, Sort: "top"},
+		"other keyword":    {Subreddits: []string{"golang", "rust"}, Sort: "new", Keyword: "generics"},
+	} {
+		if _, err := DecodeCursor(encoded, other); !errors.Is(err, ErrInvalidCursor) {
+			t.Errorf("%s: DecodeCursor() error = %v, want ErrInvalidCursor", name, err)
+		}
+	}
+	if _, err := DecodeCursor("not a cursor!", params); !errors.Is(err, ErrInvalidCursor) {
+		t.Errorf("DecodeCursor(garbage) error = %v, want ErrInvalidCursor", err)
+	}
+}
@@ -0,0 +1,169 @@
+package reddit
+
+import (
+	"encoding/json"
+	"fmt"
+	"path"
+	"strings"
+	"time"
+)
+
+// Post kinds a PostFilter can select
+const (
+	KindSelf    = "self"
+	KindLink    = "link"
+	KindImage   = "image"
+	KindVideo   = "video"
+	KindGallery = "gallery"
+)
+
+// Modes for NSFW and spoiler posts
+const (
+	FilterInclude = "include"
+	FilterExclude = "exclude"
+	FilterOnly    = "only"
+)
+
+// PostFilter narrows a listing down to the posts a user asked for. Zero
+// values don't filter; list fields match case-insensitively.
+type PostFilter struct {
+	MinScore       *int          `json:"min_score,omitempty"`
+	MinComments    int           `json:"min_comments,omitempty"`
+	MaxAge         time.Duration `json:"max_age,omitempty"`
+	NSFW           string        `json:"nsfw,omitempty"`     // include (default), exclude or only
+	Spoilers       string        `json:"spoilers,omitempty"` // include (default), exclude or only
+	Flairs         []string      `json:"flairs,omitempty"`   // Keep only posts with one of these flairs
+	ExcludeFlairs  []string      `json:"exclude_flairs,omitempty"`
+	Domains        []string      `json:"domains,omitempty"` // Keep only posts from these domains or their subdomains
+	ExcludeDomains []string      `json:"exclude_domains,omitempty"`
+	Kinds          []string      `json:"kinds,omitempty"` // Keep only these post kinds
+}
+
+// Validate checks the modes and kinds are ones the filter understands
+func (f *PostFilter) Validate() error {
+	for name, mode := range map[string]string{"nsfw": f.NSFW, "spoilers": f.Spoilers} {
+		switch mode {
+		case "", FilterInclude, FilterExclude, FilterOnly:
+		default:
+			return fmt.Errorf("%s must be include, exclude or only", name)
+		}
+	}
+	for _, kind := range f.Kinds {
+		switch kind {
+		case KindSelf, KindLink, KindImage, KindVideo, KindGallery:
+		default:
+			return fmt.Errorf("unknown post kind %q", kind)
+		}
+	}
+	if f.MaxAge < 0 {
+		return fmt.Errorf("max age must be positive")
+	}
+	return nil
+}
+
+// IsZero reports whether the filter lets every post through
+func (f *PostFilter) IsZero() bool {
+	return f == nil || f.key() == "{}"
+}
+
+// key is a stable encoding of the filter, used to tie cursors to it
+func (f *PostFilter) key() string {
+	if f == nil {
+		return "{}"
+	}
+	data, _ := json.Marshal(f)
+	return string(data)
+}
+
+// Match reports whether post passes the filter at time now
+func (f *PostFilter) Match(post RedditPost, now time.Time) bool {
+	if f == nil {
+		return true
+	}
+	if f.MinScore != nil && post.Score < *f.MinScore {
+		return false
+	}
+	if post.NumComments < f.MinComments {
+		return false
+	}
+	if f.MaxAge > 0 && now.Sub(post.CreatedUTC.Time) > f.MaxAge {
+		return false
+	}
+	if !matchMode(f.NSFW, post.Over18) || !matchMode(f.Spoilers, post.Spoiler) {
+		return false
+	}
+	if len(f.Flairs) > 0 && !containsFold(f.Flairs, post.LinkFlair) {
+		return false
+	}
+	if post.LinkFlair != "" && containsFold(f.ExcludeFlairs, post.LinkFlair) {
+		return false
+	}
+	if len(f.Domains) > 0 && !matchDomain(f.Domains, post.Domain) {
+		return false
+	}
+	if matchDomain(f.ExcludeDomains, post.Domain) {
+		return false
+	}
+	if len(f.Kinds) > 0 && !containsFold(f.Kinds, PostKind(post)) {
+		return false
+	}
+	return true
+}
+
+// PostKind classifies a post as self, link, image, video or gallery
+func PostKind(post RedditPost) string {
+	switch {
+	case post.IsGallery:
+		return KindGallery
+	case post.IsVideo || strings.HasSuffix(post.PostHint, ":video"):
+		return KindVideo
+	case post.PostHint == "image" || isImageURL(post.URL):
+		return KindImage
+	case post.IsSelf:
+		return KindSelf
+	default:
+		return KindLink
+	}
+}
+
+// isImageURL recognizes direct links to image files
+func isImageURL(rawURL string) bool {
+	switch strings.ToLower(path.Ext(strings.SplitN(rawURL, "?", 2)[0])) {
+	case ".jpg", ".jpeg", ".png", ".gif", ".webp":
+		return true
+	}
+	return false
+}
+
+// matchMode applies an include/exclude/only mode to a post flag
+func matchMode(mode string, flagged bool) bool {
+	switch mode {
+	case FilterExclude:
+		return !flagged
+	case FilterOnly:
+		return flagged
+	default:
+		return true
+	}
+}
+
+// matchDomain reports whether domain is one of domains or a subdomain of one
+func matchDomain(domains []string, domain string) bool {
+	domain = strings.ToLower(domain)
+	for _, d := range domains {
+		d = strings.ToLower(strings.TrimPrefix(d, "www."))
+		if domain == d || strings.HasSuffix(domain, "."+d) {
+			return true
+		}
+	}
+	return false
+}
+
+func containsFold(values []string, value string) bool {
+	for _, v := range values {
+		if strings.EqualFold(v, value) {
+			return true
+		}
+	}
+	return false
+}
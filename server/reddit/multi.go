@@ -0,0 +1,290 @@
+package reddit
+
+import (
+	"context"
+	"math"
+	"strings"
+	"sync"
+	"time"
+)
+
+const (
+	// defaultFetchConcurrency bounds how many subreddits are fetched at once
+	defaultFetchConcurrency = 4
+
+	// maxFilterRounds bounds how many pages per subreddit one filtered fetch
+	// reads while collecting Limit matching posts
+	maxFilterRounds = 5
+)
+
+// MultiFetchParams holds the parameters for fetching several subreddits at once
+type MultiFetchParams struct {
+	Subreddits  []string
+	Keyword     string // Searches each subreddit when set
+	Sort        string
+	TimeRange   string
+	Limit       int         // Posts per subreddit and size of the merged result
+	Filter      *PostFilter // Only posts matching it are returned
+	Cursor      *FeedCursor // Continues from a previous page when set
+	Concurrency int
+}
+
+// SubredditError reports a subreddit that couldn't be fetched
+type SubredditError struct {
+	Subreddit string `json:"subreddit"`
+	Error     string `json:"error"`
+	Err       error  `json:"-"`
+}
+
+// MultiFetchResult is the merged listing plus any per-subreddit failures.
+// Cursor continues the feed and is nil once every subreddit is exhausted.
+type MultiFetchResult struct {
+	Posts  []RedditPost     `json:"posts"`
+	Errors []SubredditError `json:"errors"`
+	Cursor *FeedCursor      `json:"-"`
+
+	// AllFailed is set when no subreddit could be fetched at all
+	AllFailed bool `json:"-"`
+}
+
+// FetchMulti fetches subreddits in parallel (bounded by Concurrency) and merges
+// the results into one list ordered by the requested sort. A failing subreddit
+// doesn't fail the others; it is reported in Errors and retried from the same
+// position on the next page.
+//
+// With a Filter, posts that don't match are skipped and further pages are
+// read until Limit posts match, the subreddits run out, a fetch fails or
+// maxFilterRounds pages have been read.
+func (c *RedditClient) FetchMulti(ctx context.Context, params MultiFetchParams) *MultiFetchResult {
+	// Cursor positions are keyed by these names, so r/GoLang and r/golang
+	// must be the same subreddit
+	params.Subreddits = normalizeSubreddits(params.Subreddits)
+
+	now := time.Now()
+	keep := func(post RedditPost) bool { return params.Filter.Match(post, now) }
+	seen := map[string]bool{}
+
+	result := &MultiFetchResult{Posts: []RedditPost{}, Errors: []SubredditError{}, Cursor: params.Cursor}
+	for round := 0; round < maxFilterRounds; round++ {
+		need := params.Limit - len(result.Posts)
+		if params.Limit <= 0 {
+			need = 0
+		}
+
+		page := c.fetchRound(ctx, params, result.Cursor, need, seen, keep)
+		result.Posts = append(result.Posts, page.Posts...)
+		result.Errors = page.Errors
+		result.Cursor = page.Cursor
+		if round == 0 {
+			result.AllFailed = page.AllFailed
+		}
+
+		done := params.Limit <= 0 || len(result.Posts) >= params.Limit
+		if done || params.Filter.IsZero() || result.Cursor == nil || len(page.Errors) > 0 || ctx.Err() != nil {
+			break
+		}
+	}
+
+	return result
+}
+
+// normalizeSubreddits lowercases subreddit names and drops repeats, keeping
+// the first occurrence's position
+func normalizeSubreddits(subreddits []string) []string {
+	normalized := make([]string, 0, len(subreddits))
+	added := make(map[string]bool, len(subreddits))
+	for _, subreddit := range subreddits {
+		subreddit = strings.ToLower(subreddit)
+		if !added[subreddit] {
+			added[subreddit] = true
+			normalized = append(normalized, subreddit)
+		}
+	}
+	return normalized
+}
+
+// fetchRound fetches the next page of every subreddit that isn't exhausted
+// and merges up to need posts that pass keep (no limit when need <= 0)
+func (c *RedditClient) fetchRound(ctx context.Context, params MultiFetchParams, previous *FeedCursor, need int, seen map[string]bool, keep func(RedditPost) bool) *MultiFetchResult {
+	concurrency := params.Concurrency
+	if concurrency <= 0 {
+		concurrency = defaultFetchConcurrency
+	}
+
+	if previous == nil {
+		previous = &FeedCursor{}
+	}
+
+	listings := make([][]RedditPost, len(params.Subreddits))
+	nextAfter := make([]string, len(params.Subreddits))
+	errs := make([]error, len(params.Subreddits))
+
+	var wg sync.WaitGroup
+	sem := make(chan struct{}, concurrency)
+	fetched := 0
+	for i, subreddit := range params.Subreddits {
+		if previous.isDone(subreddit) {
+			continue
+		}
+		fetched++
+
+		wg.Add(1)
+		go func(i int, subreddit string) {
+			defer wg.Done()
+
+			select {
+			case sem <- struct{}{}:
+				defer func() { <-sem }()
+			case <-ctx.Done():
+				errs[i] = ctx.Err()
+				return
+			}
+
+			after := previous.After[subreddit]
+			if params.Keyword != "" {
+				listings[i], nextAfter[i], errs[i] = c.searchPostsPage(ctx, SearchPostsParams{
+					Subreddit: subreddit,
+					Keyword:   params.Keyword,
+					Sort:      params.Sort,
+					Limit:     params.Limit,
+					After:     after,
+				})
+			} else {
+				listings[i], nextAfter[i], errs[i] = c.fetchPostsPage(ctx, FetchPostsParams{
+					Subreddit: subreddit,
+					Sort:      params.Sort,
+					TimeRange: params.TimeRange,
+					Limit:     params.Limit,
+					After:     after,
+				})
+			}
+		}(i, subreddit)
+	}
+	wg.Wait()
+
+	result := &MultiFetchResult{Errors: []SubredditError{}}
+	for i, err := range errs {
+		if err != nil {
+			result.Errors = append(result.Errors, SubredditError{
+				Subreddit: params.Subreddits[i],
+				Error:     err.Error(),
+				Err:       err,
+			})
+		}
+	}
+	result.AllFailed = fetched > 0 && len(result.Errors) == fetched
+
+	var consumed []int
+	result.Posts, consumed = mergeListings(listings, params.Sort, need, seen, keep)
+
+	// Each subreddit resumes after the last post it contributed to this page,
+	// so posts cut by the limit are served next time instead of skipped
+	next := &FeedCursor{Query: queryFingerprint(params), After: map[string]string{}}
+	for i, subreddit := range params.Subreddits {
+		switch {
+		case previous.isDone(subreddit):
+			next.Done = append(next.Done, subreddit)
+		case errs[i] != nil || consumed[i] == 0:
+			if after := previous.After[subreddit]; after != "" {
+				next.After[subreddit] = after
+			}
+		case consumed[i] < len(listings[i]):
+			next.After[subreddit] = listings[i][consumed[i]-1].Name
+		case nextAfter[i] != "":
+			next.After[subreddit] = nextAfter[i]
+		default:
+			next.Done = append(next.Done, subreddit)
+		}
+	}
+	if len(next.Done) < len(params.Subreddits) {
+		result.Cursor = next
+	}
+
+	return result
+}
+
+// mergeListings combines per-subreddit listings into one list ordered by sort,
+// dropping posts keep rejects and duplicates: the same post fetched twice
+// (e.g. via r/all) and crossposts of a post that is already in seen. seen
+// carries over between the rounds of one FetchMulti call, not between the
+// pages a cursor continues, so a crosspost can reappear on a later page.
+//
+// It repeatedly takes the best-ranked head among the listings, stopping after
+// limit posts (no limit when limit <= 0). Each listing keeps its own order, so
+// consumed[i] is how many posts from the front of listing i were used, whether
+// merged, filtered out or dropped as duplicates.
+func mergeListings(listings [][]RedditPost, sortBy string, limit int, seen map[string]bool, keep func(RedditPost) bool) ([]RedditPost, []int) {
+	less := sortFunc(sortBy)
+	consumed := make([]int, len(listings))
+	merged := []RedditPost{}
+
+	for limit <= 0 || len(merged) < limit {
+		best := -1
+		for i, listing := range listings {
+			if consumed[i] == len(listing) {
+				continue
+			}
+			// Ties go to the earlier listing, keeping the merge stable
+			if best < 0 || less(listing[consumed[i]], listings[best][consumed[best]]) {
+				best = i
+			}
+		}
+		if best < 0 {
+			break
+		}
+
+		post := listings[best][consumed[best]]
+		consumed[best]++
+
+		if !keep(post) {
+			continue
+		}
+
+		// Heads are taken best-ranked first, so the copy kept is the best-ranked one
+		key := dedupeKey(post)
+		if seen[key] {
+			continue
+		}
+		seen[key] = true
+		merged = append(merged, post)
+	}
+
+	return merged, consumed
+}
+
+// dedupeKey identifies the original post a listing entry refers to
+func dedupeKey(post RedditPost) string {
+	if post.CrosspostParent != "" {
+		return strings.TrimPrefix(post.CrosspostParent, "t3_")
+	}
+	return post.ID
+}
+
+// sortFunc returns the "ranks before" comparison for a listing or search sort
+func sortFunc(sortBy string) func(a, b RedditPost) bool {
+	switch sortBy {
+	case "new":
+		return func(a, b RedditPost) bool { return a.CreatedUTC.After(b.CreatedUTC.Time) }
+	case "top":
+		return func(a, b RedditPost) bool { return a.Score > b.Score }
+	case "comments", "controversial":
+		return func(a, b RedditPost) bool { return a.NumComments > b.NumComments }
+	default:
+		// hot, rising, relevance: approximate Reddit's hot ranking
+		return func(a, b RedditPost) bool { return hotScore(a) > hotScore(b) }
+	}
+}
+
+// hotScore is Reddit's classic hot ranking: the order of magnitude of the
+// score plus a bonus that grows with age, so newer posts need fewer votes
+func hotScore(post RedditPost) float64 {
+	score := float64(post.Score)
+	order := math.Log10(math.Max(math.Abs(score), 1))
+	sign := 0.0
+	if score > 0 {
+		sign = 1
+	} else if score < 0 {
+		sign = -1
+	}
+	return sign*order + (float64(post.CreatedUTC.Unix())-1134028003)/45000
+}
@@ -0,0 +1,183 @@
+package reddit
+
+import (
+	"context"
+	"fmt"
+	"io"
+	"log"
+	"net/http"
+	"net/url"
+	"os"
+	"sync"
+	"time"
+
+	"re2no/internal/config"
+
+	"golang.org/x/oauth2"
+	"golang.org/x/oauth2/clientcredentials"
+)
+
+const (
+	publicBaseURL = "https://www.reddit.com"
+	oauthBaseURL  = "https://oauth.reddit.com"
+	tokenURL      = "https://www.reddit.com/api/v1/access_token"
+
+	// oauthRequestsPerWindow is Reddit's budget for OAuth clients
+	oauthRequestsPerWindow = 100
+)
+
+// defaultUserAgent identifies the app to Reddit; override with REDDIT_USER_AGENT
+var defaultUserAgent = "web:re2no:v1.0.0 (+https://github.com/Dipstick713/Re2no)"
+
+// appAuth holds the app-only (client credentials) token source; nil means
+// requests go to the public .json endpoints unauthenticated
+var appAuth struct {
+	mu     sync.Mutex
+	config *clientcredentials.Config
+	source oauth2.TokenSource
+}
+
+// Init configures Reddit API access from the environment.
+//
+// REDDIT_LISTING_TIMEOUT, REDDIT_COMMENTS_TIMEOUT and REDDIT_ACCOUNT_TIMEOUT
+// (Go durations such as "15s") override DefaultTimeouts. With REDDIT_CLIENT_ID and REDDIT_CLIENT_SECRET set, requests use app-only OAuth
+// against oauth.reddit.com and users can link their accounts; otherwise requests
+// fall back to the public endpoints. REDDIT_USER_AGENT sets the User-Agent
+// Reddit asks every client to send.
+func Init() {
+	if userAgent := os.Getenv("REDDIT_USER_AGENT"); userAgent != "" {
+		defaultUserAgent = userAgent
+	}
+
+	DefaultTimeouts.Listing = config.Duration("REDDIT_LISTING_TIMEOUT", DefaultTimeouts.Listing)
+	DefaultTimeouts.Comments = config.Duration("REDDIT_COMMENTS_TIMEOUT", DefaultTimeouts.Comments)
+	DefaultTimeouts.Account = config.Duration("REDDIT_ACCOUNT_TIMEOUT", DefaultTimeouts.Account)
+
+	clientID := os.Getenv("REDDIT_CLIENT_ID")
+	clientSecret := os.Getenv("REDDIT_CLIENT_SECRET")
+	if clientID == "" || clientSecret == "" {
+		log.Println("[Reddit] No REDDIT_CLIENT_ID/REDDIT_CLIENT_SECRET set, using public endpoints")
+		return
+	}
+
+	appAuth.mu.Lock()
+	defer appAuth.mu.Unlock()
+
+	appAuth.config = &clientcredentials.Config{
+		ClientID:     clientID,
+		ClientSecret: clientSecret,
+		TokenURL:     tokenURL,
+		AuthStyle:    oauth2.AuthStyleInHeader,
+	}
+	appAuth.source = newAppTokenSource(appAuth.config)
+	initAccountOAuth(clientID, clientSecret)
+
+	// OAuth clients get a larger budget; response headers fine-tune it from here
+	sharedTransport.limiter = newRateLimiter(oauthRequestsPerWindow, time.Minute)
+
+	log.Println("[Reddit] Using app-only OAuth via oauth.reddit.com")
+}
+
+// newAppTokenSource returns a token source that caches the app token and
+// fetches a new one when it expires
+func newAppTokenSource(config *clientcredentials.Config) oauth2.TokenSource {
+	return config.TokenSource(tokenContext(context.Background()))
+}
+
+// appTokenSource returns the app-only token source, or nil when OAuth isn't configured
+func appTokenSource() oauth2.TokenSource {
+	appAuth.mu.Lock()
+	defer appAuth.mu.Unlock()
+	return appAuth.source
+}
+
+// resetAppToken drops the cached token so the next request fetches a new one
+func resetAppToken() {
+	appAuth.mu.Lock()
+	defer appAuth.mu.Unlock()
+
+	if appAuth.config != nil {
+		appAuth.source = newAppTokenSource(appAuth.config)
+	}
+}
+
+// userAgentTransport sets the app's User-Agent on token requests
+type userAgentTransport struct{}
+
+func (userAgentTransport) RoundTrip(req *http.Request) (*http.Response, error) {
+	req = req.Clone(req.Context())
+	req.Header.Set("User-Agent", defaultUserAgent)
+	return http.DefaultTransport.RoundTrip(req)
+}
+
+// get fetches a Reddit listing path (e.g. "/r/golang/hot") and returns the raw body.
+// It uses oauth.reddit.com when app credentials are configured and the public
+// .json endpoints otherwise.
+func (c *RedditClient) get(ctx context.Context, path string, params url.Values) ([]byte, error) {
+	source := appTokenSource()
+	body, status, err := c.request(ctx, path, params, source)
+	if err != nil {
+		return nil, err
+	}
+
+	// The token may have been revoked before it expired; get a new one once
+	if status == http.StatusUnauthorized && source != nil {
+		log.Println("[Reddit] App token rejected, fetching a new one")
+		resetAppToken()
+		if body, status, err = c.request(ctx, path, params, appTokenSource()); err != nil {
+			return nil, err
+		}
+	}
+
+	if status != http.StatusOK {
+		return nil, fmt.Errorf("reddit API returned status %d: %s", status, string(body))
+	}
+	return body, nil
+}
+
+// request performs a GET against oauth.reddit.com with a token from source,
+// or against the public .json endpoint when source is nil
+func (c *RedditClient) request(ctx context.Context, path string, params url.Values, source oauth2.TokenSource) ([]byte, int, error) {
+	fullURL := publicBaseURL + path + ".json"
+	var token *oauth2.Token
+	if source != nil {
+		var err error
+		if token, err = source.Token(); err != nil {
+			return nil, 0, fmt.Errorf("failed to get reddit token: %w", err)
+		}
+		fullURL = oauthBaseURL + path
+	}
+	// raw_json=1 stops Reddit from HTML-escaping the payload (e.g. &amp; in preview URLs)
+	query := url.Values{"raw_json": {"1"}}
+	for key, values := range params {
+		query[key] = values
+	}
+	fullURL += "?" + query.Encode()
+
+	req, err := http.NewRequestWithContext(ctx, "GET", fullURL, nil)
+	if err != nil {
+		return nil, 0, fmt.Errorf("failed to create request: %w", err)
+	}
+
+	userAgent := c.UserAgent
+	if userAgent == "" {
+		userAgent = defaultUserAgent
+	}
+	req.Header.Set("User-Agent", userAgent)
+	if token != nil {
+		token.SetAuthHeader(req)
+	}
+
+	resp, err := c.HTTPClient.Do(req)
+	if err != nil {
+		return nil, 0, err
+	}
+	defer resp.Body.Close()
+
+	body, err := io.ReadAll(resp.Body)
+	if err != nil {
+		return nil, 0, fmt.Errorf("failed to read response: %w", err)
+	}
+
+	return body, resp.StatusCode, nil
+}
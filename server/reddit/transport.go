@@ -0,0 +1,205 @@
+package reddit
+
+import (
+	"context"
+	"fmt"
+	"math"
+	"net/http"
+	"strconv"
+	"sync"
+	"time"
+
+	"re2no/internal/httpretry"
+)
+
+// Defaults for the shared transport. Reddit allows unauthenticated clients a
+// small budget per window and reports the real numbers in response headers.
+const (
+	defaultRequestsPerWindow = 10
+	defaultRateWindow        = time.Minute
+	defaultMaxRetries        = 3
+	defaultBaseDelay         = 500 * time.Millisecond
+	defaultMaxDelay          = 8 * time.Second
+	// defaultMaxWait is the longest a request will queue for rate limit budget
+	// before giving up with a RateLimitedError instead of blocking the caller
+	defaultMaxWait = 10 * time.Second
+)
+
+// RateLimitedError is returned when Reddit's rate limit is exhausted and the
+// request can't be retried within the transport's wait budget
+type RateLimitedError struct {
+	RetryAfter time.Duration
+}
+
+func (e *RateLimitedError) Error() string {
+	return fmt.Sprintf("reddit rate limit exceeded, retry after %s", e.RetryAfter.Round(time.Second))
+}
+
+// Transport is an http.RoundTripper that paces requests with a token bucket
+// driven by Reddit's X-Ratelimit-* headers and retries 429 and 5xx responses
+// with exponential backoff and jitter
+type Transport struct {
+	Base       http.RoundTripper
+	MaxRetries int
+	BaseDelay  time.Duration
+	MaxDelay   time.Duration
+	MaxWait    time.Duration
+
+	limiter *rateLimiter
+}
+
+// NewTransport wraps base (http.DefaultTransport when nil) with pacing and retries
+func NewTransport(base http.RoundTripper) *Transport {
+	if base == nil {
+		base = http.DefaultTransport
+	}
+	return &Transport{
+		Base:       base,
+		MaxRetries: defaultMaxRetries,
+		BaseDelay:  defaultBaseDelay,
+		MaxDelay:   defaultMaxDelay,
+		MaxWait:    defaultMaxWait,
+		limiter:    newRateLimiter(defaultRequestsPerWindow, defaultRateWindow),
+	}
+}
+
+// sharedTransport is used by every RedditClient so they draw from one rate limit budget
+var sharedTransport = NewTransport(nil)
+
+// RoundTrip implements http.RoundTripper
+func (t *Transport) RoundTrip(req *http.Request) (*http.Response, error) {
+	ctx := req.Context()
+
+	for attempt := 0; ; attempt++ {
+		if err := t.limiter.wait(ctx, t.MaxWait); err != nil {
+			return nil, err
+		}
+
+		attemptReq := req
+		if attempt > 0 {
+			var err error
+			if attemptReq, err = httpretry.Rewind(req); err != nil {
+				return nil, err
+			}
+		}
+
+		resp, err := t.Base.RoundTrip(attemptReq)
+		if err != nil {
+			if attempt >= t.MaxRetries || ctx.Err() != nil {
+				return nil, err
+			}
+			if err := httpretry.Sleep(ctx, t.backoff(attempt)); err != nil {
+				return nil, err
+			}
+			continue
+		}
+
+		t.limiter.update(resp.Header)
+
+		if resp.StatusCode != http.StatusTooManyRequests && resp.StatusCode < 500 {
+			return resp, nil
+		}
+
+		// Prefer the server's hint over our own backoff schedule
+		delay := t.backoff(attempt)
+		retryAfter, hinted := httpretry.RetryAfter(resp.Header, "Retry-After", "X-Ratelimit-Reset")
+		if hinted {
+			delay = retryAfter
+		}
+
+		if attempt >= t.MaxRetries || delay > t.MaxWait {
+			if resp.StatusCode == http.StatusTooManyRequests {
+				resp.Body.Close()
+				if !hinted {
+					retryAfter = delay
+				}
+				return nil, &RateLimitedError{RetryAfter: retryAfter}
+			}
+			// Out of retries on a 5xx; let the caller see the response
+			return resp, nil
+		}
+
+		resp.Body.Close()
+		if err := httpretry.Sleep(ctx, delay); err != nil {
+			return nil, err
+		}
+	}
+}
+
+// backoff returns the delay before retry attempt+1
+func (t *Transport) backoff(attempt int) time.Duration {
+	return httpretry.Backoff(attempt, t.BaseDelay, t.MaxDelay)
+}
+
+// rateLimiter is a token bucket whose budget and refill rate follow the most
+// recent X-Ratelimit-Remaining / X-Ratelimit-Reset headers, spreading the
+// remaining requests evenly over what's left of the window
+type rateLimiter struct {
+	mu       sync.Mutex
+	tokens   float64
+	capacity float64
+	rate     float64 // tokens per second
+	updated  time.Time
+}
+
+func newRateLimiter(requests int, window time.Duration) *rateLimiter {
+	return &rateLimiter{
+		tokens:   float64(requests),
+		capacity: float64(requests),
+		rate:     float64(requests) / window.Seconds(),
+		updated:  time.Now(),
+	}
+}
+
+// refill adds the tokens accrued since the last update; callers hold mu
+func (l *rateLimiter) refill(now time.Time) {
+	l.tokens = math.Min(l.capacity, l.tokens+now.Sub(l.updated).Seconds()*l.rate)
+	l.updated = now
+}
+
+// wait blocks until a token is available. If that would take longer than
+// maxWait it fails fast with a RateLimitedError.
+func (l *rateLimiter) wait(ctx context.Context, maxWait time.Duration) error {
+	l.mu.Lock()
+	now := time.Now()
+	l.refill(now)
+
+	var delay time.Duration
+	if l.tokens < 1 {
+		delay = time.Duration((1 - l.tokens) / l.rate * float64(time.Second))
+		if delay > maxWait {
+			l.mu.Unlock()
+			return &RateLimitedError{RetryAfter: delay}
+		}
+	}
+	// Reserve the token now so concurrent callers queue behind us
+	l.tokens--
+	l.mu.Unlock()
+
+	if delay > 0 {
+		return httpretry.Sleep(ctx, delay)
+	}
+	return nil
+}
+
+// update resizes the bucket from Reddit's rate limit headers
+func (l *rateLimiter) update(header http.Header) {
+	remaining, err := strconv.ParseFloat(header.Get("X-Ratelimit-Remaining"), 64)
+	if err != nil {
+		return
+	}
+	reset, err := strconv.ParseFloat(header.Get("X-Ratelimit-Reset"), 64)
+	if err != nil || reset <= 0 {
+		return
+	}
+
+	l.mu.Lock()
+	defer l.mu.Unlock()
+
+	l.refill(time.Now())
+	// Allow a small burst, but never more than Reddit says is left
+	l.capacity = math.Max(1, math.Min(remaining, defaultRequestsPerWindow))
+	l.tokens = math.Min(l.tokens, remaining)
+	// With nothing left, the next token arrives when the window resets
+	l.rate = math.Max(remaining, 1) / reset
+}
@@ -0,0 +1,163 @@
+package reddit
+
+import (
+	"bytes"
+	"fmt"
+	"math"
+	"strconv"
+	"time"
+)
+
+// UnixTime is a timestamp Reddit sends as (fractional) seconds since the
+// epoch. It marshals back to the same form so API clients see Reddit's format.
+type UnixTime struct {
+	time.Time
+}
+
+// UnmarshalJSON accepts a number of seconds; null leaves the zero time
+func (t *UnixTime) UnmarshalJSON(data []byte) error {
+	if bytes.Equal(data, []byte("null")) {
+		return nil
+	}
+	seconds, err := strconv.ParseFloat(string(data), 64)
+	if err != nil {
+		return fmt.Errorf("invalid unix timestamp %s", data)
+	}
+	whole, frac := math.Modf(seconds)
+	t.Time = time.Unix(int64(whole), int64(frac*1e9)).UTC()
+	return nil
+}
+
+// MarshalJSON writes seconds since the epoch, or 0 for the zero time
+func (t UnixTime) MarshalJSON() ([]byte, error) {
+	if t.IsZero() {
+		return []byte("0"), nil
+	}
+	return []byte(strconv.FormatFloat(float64(t.UnixMilli())/1000, 'f', -1, 64)), nil
+}
+
+// ImageSource is one rendition of a preview or gallery image
+type ImageSource struct {
+	URL    string `json:"url"`
+	Width  int    `json:"width"`
+	Height int    `json:"height"`
+}
+
+// PreviewImage is a preview image with its downscaled renditions
+type PreviewImage struct {
+	ID          string        `json:"id"`
+	Source      ImageSource   `json:"source"`
+	Resolutions []ImageSource `json:"resolutions"`
+}
+
+// Preview holds the images Reddit generated for a link or image post
+type Preview struct {
+	Images  []PreviewImage `json:"images"`
+	Enabled bool           `json:"enabled"`
+}
+
+// GalleryItem is one entry of a gallery post, in display order
+type GalleryItem struct {
+	ID          int    `json:"id"`
+	MediaID     string `json:"media_id"`
+	Caption     string `json:"caption,omitempty"`
+	OutboundURL string `json:"outbound_url,omitempty"`
+}
+
+// GalleryData lists a gallery post's items; the media itself is in MediaMetadata
+type GalleryData struct {
+	Items []GalleryItem `json:"items"`
+}
+
+// MediaSource is the full-size rendition of a gallery or inline media item.
+// Animated items carry GIF and MP4 links instead of URL.
+type MediaSource struct {
+	URL    string `json:"u,omitempty"`
+	GIF    string `json:"gif,omitempty"`
+	MP4    string `json:"mp4,omitempty"`
+	Width  int    `json:"x"`
+	Height int    `json:"y"`
+}
+
+// MediaMetadata describes one media item referenced by gallery_data
+type MediaMetadata struct {
+	ID       string      `json:"id"`
+	Status   string      `json:"status"` // "valid" once processed
+	Kind     string      `json:"e"`      // Image or AnimatedImage
+	MimeType string      `json:"m"`
+	Source   MediaSource `json:"s"`
+}
+
+// RedditVideo is a video hosted on v.redd.it
+type RedditVideo struct {
+	FallbackURL string `json:"fallback_url"`
+	HLSURL      string `json:"hls_url,omitempty"`
+	DashURL     string `json:"dash_url,omitempty"`
+	Duration    int    `json:"duration"`
+	Width       int    `json:"width"`
+	Height      int    `json:"height"`
+	IsGIF       bool   `json:"is_gif"`
+}
+
+// OEmbed describes embedded media from another site (YouTube, Twitch, ...)
+type OEmbed struct {
+	ProviderName string `json:"provider_name"`
+	Title        string `json:"title,omitempty"`
+	ThumbnailURL string `json:"thumbnail_url,omitempty"`
+	Width        int    `json:"width,omitempty"`
+	Height       int    `json:"height,omitempty"`
+	HTML         string `json:"html,omitempty"`
+}
+
+// Media is a post's secure_media: a Reddit-hosted video or an oEmbed
+type Media struct {
+	Type        string       `json:"type,omitempty"`
+	RedditVideo *RedditVideo `json:"reddit_video,omitempty"`
+	OEmbed      *OEmbed      `json:"oembed,omitempty"`
+}
+
+// GalleryImage is a resolved gallery item
+type GalleryImage struct {
+	URL     string `json:"url"`
+	Caption string `json:"caption,omitempty"`
+	Width   int    `json:"width"`
+	Height  int    `json:"height"`
+}
+
+// GalleryImages resolves a gallery post's items to their full-size media,
+// in gallery order, skipping items Reddit hasn't processed
+func (p *RedditPost) GalleryImages() []GalleryImage {
+	if p.GalleryData == nil {
+		return nil
+	}
+
+	images := make([]GalleryImage, 0, len(p.GalleryData.Items))
+	for _, item := range p.GalleryData.Items {
+		media, ok := p.MediaMetadata[item.MediaID]
+		if !ok || media.Status != "valid" {
+			continue
+		}
+		url := media.Source.URL
+		if url == "" {
+			url = media.Source.GIF
+		}
+		if url == "" {
+			continue
+		}
+		images = append(images, GalleryImage{
+			URL:     url,
+			Caption: item.Caption,
+			Width:   media.Source.Width,
+			Height:  media.Source.Height,
+		})
+	}
+	return images
+}
+
+// PreviewImageURL returns the full-size preview image, if Reddit generated one
+func (p *RedditPost) PreviewImageURL() string {
+	if p.Preview == nil || len(p.Preview.Images) == 0 {
+		return ""
+	}
+	return p.Preview.Images[0].Source.URL
+}
@@ -0,0 +1,83 @@
+package schedule
+
+import (
+	"testing"
+	"time"
+)
+
+func TestNext(t *testing.T) {
+	berlin, err := time.LoadLocation("Europe/Berlin")
+	if err != nil {
+		t.Fatal(err)
+	}
+	utc := func(value string) time.Time {
+		parsed, err := time.Parse("2006-01-02 15:04", value)
+		if err != nil {
+			t.Fatal(err)
+		}
+		return parsed
+	}
+
+	tests := []struct {
+		name string
+		expr string
+		from time.Time
+		want time.Time
+	}{
+		{"every minute", "* * * * *", utc("2026-03-10 08:00"), utc("2026-03-10 08:01")},
+		{"seconds are dropped", "* * * * *", utc("2026-03-10 08:00").Add(59 * time.Second), utc("2026-03-10 08:01")},
+		{"step", "*/15 * * * *", utc("2026-03-10 08:07"), utc("2026-03-10 08:15")},
+		{"step from a value", "5/20 * * * *", utc("2026-03-10 08:30"), utc("2026-03-10 08:45")},
+		{"daily later today", "0 8 * * *", utc("2026-03-10 07:59"), utc("2026-03-10 08:00")},
+		{"daily tomorrow", "0 8 * * *", utc("2026-03-10 08:00"), utc("2026-03-11 08:00")},
+		{"list", "0 8,20 * * *", utc("2026-03-10 09:00"), utc("2026-03-10 20:00")},
+		{"weekdays skip the weekend", "0 9 * * 1-5", utc("2026-03-13 10:00"), utc("2026-03-16 09:00")},
+		{"sunday as 7", "0 9 * * 7", utc("2026-03-10 10:00"), utc("2026-03-15 09:00")},
+		{"sunday as 0", "0 9 * * 0", utc("2026-03-10 10:00"), utc("2026-03-15 09:00")},
+		{"day of month or day of week", "0 0 1 * 1", utc("2026-03-10 10:00"), utc("2026-03-16 00:00")},
+		{"day of month only", "0 0 1 * *", utc("2026-03-10 10:00"), utc("2026-04-01 00:00")},
+		{"month rolls into next year", "0 0 1 1 *", utc("2026-03-10 10:00"), utc("2027-01-01 00:00")},
+		{"31st skips short months", "0 0 31 * *", utc("2026-04-01 00:00"), utc("2026-05-31 00:00")},
+		{"leap day", "0 0 29 2 *", utc("2026-03-01 00:00"), utc("2028-02-29 00:00")},
+		{"never matches", "0 0 30 2 *", utc("2026-01-01 00:00"), time.Time{}},
+		{"hourly", "@hourly", utc("2026-03-10 08:30"), utc("2026-03-10 09:00")},
+		{"weekly", "@weekly", utc("2026-03-10 08:30"), utc("2026-03-15 00:00")},
+		{"monthly", "@monthly", utc("2026-12-10 08:30"), utc("2027-01-01 00:00")},
+		{"read in the time's location", "0 8 * * *", utc("2026-07-01 07:00").In(berlin), time.Date(2026, 7, 2, 8, 0, 0, 0, berlin)},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			s, err := Parse(tt.expr)
+			if err != nil {
+				t.Fatalf("Parse(%q): %v", tt.expr, err)
+			}
+			if got := s.Next(tt.from); !got.Equal(tt.want) {
+				t.Errorf("Next(%s) = %s, want %s", tt.from, got, tt.want)
+			}
+		})
+	}
+}
+
+func TestParseErrors(t *testing.T) {
+	tests := []string{
+		"",
+		"* * * *",
+		"* * * * * *",
+		"60 * * * *",
+		"* 24 * * *",
+		"* * 0 * *",
+		"* * * 13 *",
+		"* * * * 8",
+		"5-1 * * * *",
+		"*/0 * * * *",
+		"a * * * *",
+		"@sometimes",
+	}
+
+	for _, expr := range tests {
+		if _, err := Parse(expr); err == nil {
+			t.Errorf("Parse(%q) succeeded, want an error", expr)
+		}
+	}
+}